@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Config struct {
+	// HMACSecret enables HS256 tokens.
+	HMACSecret string
+	// JWKSURL enables RS256 tokens whose keys are published as a JWKS.
+	JWKSURL  string
+	Issuer   string
+	Audience string
+	Leeway   time.Duration
+}
+
+type Claims struct {
+	Email     string   `json:"email"`
+	FirstName string   `json:"given_name"`
+	LastName  string   `json:"family_name"`
+	Phone     string   `json:"phone_number"`
+	Roles     []string `json:"roles"`
+	// AuthorizedParty (azp) or ClientID (client_id) name the application
+	// the token was issued to.
+	AuthorizedParty string `json:"azp"`
+	ClientID        string `json:"client_id"`
+	jwt.RegisteredClaims
+}
+
+type Validator struct {
+	secret []byte
+	jwks   *jwksCache
+	parser *jwt.Parser
+	leeway time.Duration
+}
+
+func NewValidator(cfg Config) (*Validator, error) {
+	if cfg.HMACSecret == "" && cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("auth: either an HMAC secret or a JWKS URL is required")
+	}
+
+	v := &Validator{leeway: cfg.Leeway}
+	var methods []string
+	if cfg.HMACSecret != "" {
+		v.secret = []byte(cfg.HMACSecret)
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if cfg.JWKSURL != "" {
+		v.jwks = newJWKSCache(cfg.JWKSURL)
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods(methods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(cfg.Leeway),
+	}
+	if cfg.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(cfg.Issuer))
+	}
+	if cfg.Audience != "" {
+		opts = append(opts, jwt.WithAudience(cfg.Audience))
+	}
+	v.parser = jwt.NewParser(opts...)
+
+	return v, nil
+}
+
+// Validate parses and verifies a compact JWT, checking signature, exp, nbf,
+// iss and aud, and returns the principal it describes.
+func (v *Validator) Validate(tokenString string) (*Principal, error) {
+	claims := &Claims{}
+	if _, err := v.parser.ParseWithClaims(tokenString, claims, v.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	clientID := claims.AuthorizedParty
+	if clientID == "" {
+		clientID = claims.ClientID
+	}
+
+	return &Principal{
+		UserID:    claims.Subject,
+		ClientID:  clientID,
+		Email:     claims.Email,
+		FirstName: claims.FirstName,
+		LastName:  claims.LastName,
+		Phone:     claims.Phone,
+		Roles:     claims.Roles,
+		ExpiresAt: claims.ExpiresAt.Add(v.leeway),
+	}, nil
+}
+
+func (v *Validator) keyFunc(token *jwt.Token) (any, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		if v.secret == nil {
+			return nil, fmt.Errorf("HMAC tokens are not accepted")
+		}
+		return v.secret, nil
+	case *jwt.SigningMethodRSA:
+		if v.jwks == nil {
+			return nil, fmt.Errorf("RSA tokens are not accepted")
+		}
+		kid, _ := token.Header["kid"].(string)
+		return v.jwks.key(kid)
+	}
+	return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+}
@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Middleware validates the Bearer token when one is sent and records the
+// outcome in the request context. It never rejects the request itself:
+// public fields such as healthCheck must stay reachable, and protected
+// resolvers report UNAUTHENTICATED through GraphQL instead.
+func Middleware(v *Validator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := Authenticate(r.Context(), v, r.Header.Get("Authorization"))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Authenticate validates an Authorization header value ("Bearer <jwt>") and
+// returns a context carrying either the principal or the failure reason.
+func Authenticate(ctx context.Context, v *Validator, header string) context.Context {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return withError(ctx, ErrMissingToken)
+	}
+
+	token = strings.TrimSpace(token)
+	p, err := v.Validate(token)
+	if err != nil {
+		return withError(ctx, err)
+	}
+	p.Token = token
+	return WithPrincipal(ctx, p)
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrMissingToken = errors.New("missing bearer token")
+
+// Principal is the authenticated caller, built from validated JWT claims.
+type Principal struct {
+	UserID    string
+	Email     string
+	FirstName string
+	LastName  string
+	Phone     string
+	Roles     []string
+	// ClientID is the application the caller used, when the token says.
+	ClientID string
+	// Token is the raw bearer token, forwarded when calling other services
+	// on the user's behalf.
+	Token string
+	// ExpiresAt is when the token stops being accepted. Websocket
+	// connections outlive single requests, so their subscriptions end then.
+	ExpiresAt time.Time
+}
+
+// Expired reports whether p's token has expired; a zero ExpiresAt never
+// does.
+func (p *Principal) Expired() bool {
+	return !p.ExpiresAt.IsZero() && time.Now().After(p.ExpiresAt)
+}
+
+func (p *Principal) HasRole(role string) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// authResult is stored in the context for every request so resolvers can
+// tell "no token" apart from "token rejected" when reporting errors.
+type authResult struct {
+	principal *Principal
+	err       error
+}
+
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, authResult{principal: p})
+}
+
+func withError(ctx context.Context, err error) context.Context {
+	return context.WithValue(ctx, contextKey{}, authResult{err: err})
+}
+
+// FromContext returns the authenticated principal, or the reason there is
+// none. A websocket connection keeps its principal after the token expires,
+// so an expired one is rejected here.
+func FromContext(ctx context.Context) (*Principal, error) {
+	res, ok := ctx.Value(contextKey{}).(authResult)
+	if !ok {
+		return nil, ErrMissingToken
+	}
+	if res.err != nil {
+		return nil, res.err
+	}
+	if res.principal.Expired() {
+		return nil, fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+	return res.principal, nil
+}
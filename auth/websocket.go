@@ -0,0 +1,21 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+)
+
+// WebsocketInit authenticates a GraphQL websocket connection from the
+// "Authorization" entry of its connection_init payload, since browsers cannot
+// set headers on the upgrade request. Like Middleware it only records the
+// outcome; subscriptions report UNAUTHENTICATED themselves. The token is only
+// sent once, so FromContext rejects it after exp and subscriptions end then.
+func WebsocketInit(v *Validator) transport.WebsocketInitFunc {
+	return func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+		if header := payload.Authorization(); header != "" {
+			ctx = Authenticate(ctx, v, header)
+		}
+		return ctx, &payload, nil
+	}
+}
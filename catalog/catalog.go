@@ -0,0 +1,39 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+
+	"payment-service-iae/money"
+)
+
+var ErrBookNotFound = errors.New("book not found")
+
+// Book is the catalogue's authoritative view of something we sell.
+type Book struct {
+	ID    string
+	Title string
+	Price money.Money
+}
+
+// Catalog looks up authoritative book prices. Amounts sent by clients are
+// never trusted; they are checked against what the catalog says.
+type Catalog interface {
+	GetBook(ctx context.Context, bookID string) (*Book, error)
+}
+
+// toPrice reads a catalogue price in minor units of currency, which
+// defaults to IDR.
+func toPrice(amount int64, currency string) (money.Money, error) {
+	c := money.IDR
+	if currency != "" {
+		var err error
+		if c, err = money.ParseCurrency(currency); err != nil {
+			return money.Money{}, err
+		}
+	}
+	if amount <= 0 {
+		return money.Money{}, errors.New("no valid price")
+	}
+	return money.New(amount, c), nil
+}
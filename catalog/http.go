@@ -0,0 +1,65 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// HTTPCatalog reads books from the book catalogue service.
+type HTTPCatalog struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewHTTPCatalog(baseURL string) *HTTPCatalog {
+	return &HTTPCatalog{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+type bookResponse struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	// Price is in minor units of Currency, IDR when it is not given.
+	Price    int64  `json:"price"`
+	Currency string `json:"currency"`
+}
+
+// GetBook fetches GET /books/{id}.
+func (c *HTTPCatalog) GetBook(ctx context.Context, bookID string) (*Book, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/books/"+url.PathEscape(bookID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("book catalog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrBookNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("book catalog: unexpected status %d", resp.StatusCode)
+	}
+
+	var b bookResponse
+	if err := json.NewDecoder(resp.Body).Decode(&b); err != nil {
+		return nil, fmt.Errorf("book catalog: decode response: %w", err)
+	}
+	price, err := toPrice(b.Price, b.Currency)
+	if err != nil {
+		return nil, fmt.Errorf("book catalog: book %s: %w", bookID, err)
+	}
+
+	return &Book{ID: bookID, Title: b.Title, Price: price}, nil
+}
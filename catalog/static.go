@@ -0,0 +1,57 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// StaticCatalog serves prices from a fixed table, loaded from a JSON file of
+// the form {"book-123": {"title": "Go in Action", "price": 150000}}. Prices
+// are in minor units of the optional "currency", IDR by default.
+type StaticCatalog struct {
+	books map[string]Book
+}
+
+func NewStaticCatalog(books []Book) *StaticCatalog {
+	c := &StaticCatalog{books: make(map[string]Book, len(books))}
+	for _, b := range books {
+		c.books[b.ID] = b
+	}
+	return c
+}
+
+func LoadStaticCatalog(path string) (*StaticCatalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read price table: %w", err)
+	}
+
+	var entries map[string]struct {
+		Title    string `json:"title"`
+		Price    int64  `json:"price"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse price table %s: %w", path, err)
+	}
+
+	books := make([]Book, 0, len(entries))
+	for id, e := range entries {
+		price, err := toPrice(e.Price, e.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("price table %s: book %s: %w", path, id, err)
+		}
+		books = append(books, Book{ID: id, Title: e.Title, Price: price})
+	}
+	return NewStaticCatalog(books), nil
+}
+
+func (c *StaticCatalog) GetBook(ctx context.Context, bookID string) (*Book, error) {
+	b, ok := c.books[bookID]
+	if !ok {
+		return nil, ErrBookNotFound
+	}
+	return &b, nil
+}
@@ -0,0 +1,33 @@
+// Command midtrans-emulator runs the in-memory Midtrans emulator for local
+// development. Point the service at it with MIDTRANS_BASE_URL.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"payment-service-iae/midtrans/emulator"
+)
+
+func main() {
+	addr := flag.String("addr", ":9211", "address to listen on")
+	serverKey := flag.String("server-key", os.Getenv("MIDTRANS_SERVER_KEY"), "server key clients authenticate with and notifications are signed with")
+	notifyURL := flag.String("notification-url", "http://localhost:9210/notifications/midtrans", "where to post payment notifications (empty disables them)")
+	publicURL := flag.String("public-url", "", "externally reachable emulator URL used in redirect URLs (default: the request host)")
+	flag.Parse()
+
+	if *serverKey == "" {
+		log.Fatal("A server key is required: set MIDTRANS_SERVER_KEY or pass -server-key")
+	}
+
+	server := emulator.New(emulator.Config{
+		ServerKey:       *serverKey,
+		NotificationURL: *notifyURL,
+		PublicURL:       *publicURL,
+	})
+
+	log.Printf("Midtrans emulator listening on %s, notifying %s", *addr, *notifyURL)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
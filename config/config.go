@@ -4,23 +4,179 @@ import (
 	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"payment-service-iae/customer"
+	"payment-service-iae/payment"
+	"strings"
+	"time"
 )
 
 type Config struct {
 	Port                string
 	MidtransServerKey   string
-	MidtransEnvironment string
+	MidtransClientKey   string
+	MidtransEnvironment Environment
+	MidtransBaseURL     string
 	JWTSecret           string
+	JWTIssuer           string
+	JWTAudience         string
+	JWKSURL             string
+	DatabaseURL         string
+	UserServiceURL      string
+	CustomerFallback    customer.FallbackPolicy
+	BookCatalogURL      string
+	BookPricesFile      string
+	EventBroker         string
+	NATSURL             string
+	NATSStream          string
+	NATSSubjectPrefix   string
+	OutboxPollInterval  time.Duration
+	OutboxBatchSize     int
+	WebhookTimeout      time.Duration
+	WebhookPollInterval time.Duration
+	WebhookMaxAttempts  int
+	ReconcileAfter      time.Duration
+	ReconcileInterval   time.Duration
+	ReconcileJitter     time.Duration
+	ReconcileBatchSize  int
+	PaymentExpiry       payment.ExpiryPolicy
+	ExpirySweepInterval time.Duration
+	ExpiryGrace         time.Duration
+	PublicBaseURL       string
+	OptionsPolicyFile   string
+	SubscriptionPlans   string
+	PromoCodesFile      string
+	Pricing             payment.PricingPolicy
+
+	// Warnings are problems that do not stop the service from starting.
+	Warnings []string
 }
 
-func Load() *Config {
+// Load reads the configuration from the environment (and .env, if present)
+// and validates it. A misconfigured deployment gets a *ValidationError
+// listing every problem at once rather than failing on the first.
+func Load() (*Config, error) {
 	loadEnvFile()
 
-	return &Config{
-		Port:                getEnv("PORT", ""),
-		MidtransServerKey:   getEnv("MIDTRANS_SERVER_KEY", ""),
-		MidtransEnvironment: getEnv("MIDTRANS_ENV", ""),
+	v := &validator{}
+
+	cfg := &Config{
+		Port:                v.port("PORT"),
+		MidtransServerKey:   v.required("MIDTRANS_SERVER_KEY"),
+		MidtransClientKey:   getEnv("MIDTRANS_CLIENT_KEY", ""),
+		MidtransBaseURL:     v.optionalURL("MIDTRANS_BASE_URL"),
 		JWTSecret:           getEnv("JWT_SECRET", ""),
+		JWTIssuer:           getEnv("JWT_ISSUER", ""),
+		JWTAudience:         getEnv("JWT_AUDIENCE", ""),
+		JWKSURL:             v.optionalURL("JWT_JWKS_URL"),
+		DatabaseURL:         v.required("DATABASE_URL"),
+		UserServiceURL:      v.optionalURL("USER_SERVICE_URL"),
+		BookCatalogURL:      v.optionalURL("BOOK_CATALOG_URL"),
+		BookPricesFile:      getEnv("BOOK_PRICES_FILE", ""),
+		EventBroker:         v.oneOf("EVENT_BROKER", "", "", "log", "nats"),
+		NATSURL:             getEnv("NATS_URL", ""),
+		NATSStream:          getEnv("NATS_STREAM", "PAYMENTS"),
+		NATSSubjectPrefix:   getEnv("NATS_SUBJECT_PREFIX", "events."),
+		OutboxPollInterval:  v.duration("OUTBOX_POLL_INTERVAL", time.Second),
+		OutboxBatchSize:     v.positiveInt("OUTBOX_BATCH_SIZE", 100),
+		WebhookTimeout:      v.duration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookPollInterval: v.duration("WEBHOOK_POLL_INTERVAL", 2*time.Second),
+		WebhookMaxAttempts:  v.positiveInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		ReconcileAfter:      v.duration("RECONCILE_AFTER", 15*time.Minute),
+		ReconcileInterval:   v.duration("RECONCILE_INTERVAL", time.Minute),
+		ReconcileJitter:     v.nonNegativeDuration("RECONCILE_JITTER", 15*time.Second),
+		ReconcileBatchSize:  v.positiveInt("RECONCILE_BATCH_SIZE", 50),
+		PaymentExpiry: payment.ExpiryPolicy{
+			Default:  v.expiry("PAYMENT_EXPIRY", 24*time.Hour),
+			ByMethod: v.methodExpiries("PAYMENT_EXPIRY_BY_METHOD"),
+		},
+		ExpirySweepInterval: v.duration("EXPIRY_SWEEP_INTERVAL", time.Minute),
+		ExpiryGrace:         v.nonNegativeDuration("EXPIRY_GRACE", 5*time.Minute),
+		PublicBaseURL:       strings.TrimSuffix(v.optionalURL("PUBLIC_BASE_URL"), "/"),
+		OptionsPolicyFile:   getEnv("PAYMENT_OPTIONS_POLICY_FILE", ""),
+		SubscriptionPlans:   getEnv("SUBSCRIPTION_PLANS_FILE", ""),
+		PromoCodesFile:      getEnv("PROMO_CODES_FILE", ""),
+		Pricing: payment.PricingPolicy{
+			TaxRate: v.rate("TAX_RATE", 0),
+			Fees:    v.methodFees("PAYMENT_FEES"),
+		},
+	}
+
+	env, err := ParseEnvironment(getEnv("MIDTRANS_ENV", ""))
+	if err != nil {
+		v.add("MIDTRANS_ENV: %v", err)
+	}
+	cfg.MidtransEnvironment = env
+
+	fallback, err := customer.ParseFallbackPolicy(getEnv("CUSTOMER_FALLBACK_POLICY", ""))
+	if err != nil {
+		v.add("CUSTOMER_FALLBACK_POLICY: %v", err)
+	}
+	cfg.CustomerFallback = fallback
+
+	cfg.validate(v)
+	cfg.Warnings = v.warnings
+
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) validate(v *validator) {
+	if c.MidtransEnvironment != "" {
+		checkKeyPrefix(v, "MIDTRANS_SERVER_KEY", c.MidtransServerKey, c.MidtransEnvironment, "server")
+		checkKeyPrefix(v, "MIDTRANS_CLIENT_KEY", c.MidtransClientKey, c.MidtransEnvironment, "client")
+	}
+	if c.MidtransBaseURL != "" && c.MidtransEnvironment == Production {
+		v.warn("MIDTRANS_BASE_URL overrides the production Midtrans endpoints")
+	}
+
+	if c.JWTSecret == "" && c.JWKSURL == "" {
+		v.add("JWT_SECRET or JWT_JWKS_URL is required")
+	}
+	if c.JWTSecret != "" && len(c.JWTSecret) < minProductionSecretLength {
+		if c.MidtransEnvironment == Production {
+			v.add("JWT_SECRET must be at least %d bytes in production", minProductionSecretLength)
+		} else {
+			v.warn("JWT_SECRET is shorter than %d bytes", minProductionSecretLength)
+		}
+	}
+
+	if c.BookCatalogURL == "" && c.BookPricesFile == "" {
+		v.add("BOOK_CATALOG_URL or BOOK_PRICES_FILE is required so prices can be looked up")
+	}
+	if c.BookCatalogURL != "" && c.BookPricesFile != "" {
+		v.warn("both BOOK_CATALOG_URL and BOOK_PRICES_FILE are set; BOOK_PRICES_FILE is ignored")
+	}
+
+	switch c.EventBroker {
+	case "":
+		v.warn("EVENT_BROKER is not set; payment events are only delivered to webhooks")
+	case "nats":
+		if c.NATSURL == "" {
+			v.add("NATS_URL is required when EVENT_BROKER=nats")
+		}
+	}
+}
+
+// checkKeyPrefix guards against sending production traffic to the sandbox
+// (or the reverse) by matching the key prefix to the environment.
+func checkKeyPrefix(v *validator, name, key string, env Environment, kind string) {
+	if key == "" {
+		return
+	}
+	sandboxPrefix := "SB-Mid-" + kind + "-"
+	productionPrefix := "Mid-" + kind + "-"
+
+	switch env {
+	case Sandbox:
+		if !strings.HasPrefix(key, sandboxPrefix) {
+			v.add("%s must start with %q when MIDTRANS_ENV=%s", name, sandboxPrefix, env)
+		}
+	case Production:
+		if !strings.HasPrefix(key, productionPrefix) {
+			v.add("%s must start with %q when MIDTRANS_ENV=%s", name, productionPrefix, env)
+		}
 	}
 }
 
@@ -34,7 +190,7 @@ func loadEnvFile() {
 }
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
 		return value
 	}
 	return defaultValue
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/midtrans/midtrans-go"
+)
+
+type Environment string
+
+const (
+	Sandbox    Environment = "sandbox"
+	Production Environment = "production"
+)
+
+const minProductionSecretLength = 32
+
+func ParseEnvironment(s string) (Environment, error) {
+	switch env := Environment(strings.ToLower(strings.TrimSpace(s))); env {
+	case Sandbox, Production:
+		return env, nil
+	case "":
+		return "", fmt.Errorf("is required (sandbox or production)")
+	}
+	return "", fmt.Errorf("unknown environment %q (want sandbox or production)", s)
+}
+
+// Midtrans returns the SDK environment to hand to midtrans.NewClient.
+func (e Environment) Midtrans() midtrans.EnvironmentType {
+	if e == Production {
+		return midtrans.Production
+	}
+	return midtrans.Sandbox
+}
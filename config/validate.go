@@ -0,0 +1,223 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"payment-service-iae/payment"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every configuration problem found at startup.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid configuration:")
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+type validator struct {
+	problems []string
+	warnings []string
+}
+
+func (v *validator) add(format string, args ...any) {
+	v.problems = append(v.problems, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) warn(format string, args ...any) {
+	v.warnings = append(v.warnings, fmt.Sprintf(format, args...))
+}
+
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+func (v *validator) required(key string) string {
+	value := getEnv(key, "")
+	if value == "" {
+		v.add("%s is required", key)
+	}
+	return value
+}
+
+func (v *validator) port(key string) string {
+	value := v.required(key)
+	if value == "" {
+		return ""
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 65535 {
+		v.add("%s must be a port number between 1 and 65535, got %q", key, value)
+	}
+	return value
+}
+
+func (v *validator) optionalURL(key string) string {
+	value := getEnv(key, "")
+	if value == "" {
+		return ""
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add("%s must be an absolute http(s) URL, got %q", key, value)
+	}
+	return value
+}
+
+func (v *validator) duration(key string, defaultValue time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		v.add("%s must be a positive duration such as 30s or 5m, got %q", key, value)
+		return defaultValue
+	}
+	return d
+}
+
+func (v *validator) nonNegativeDuration(key string, defaultValue time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		v.add("%s must be a duration such as 0s or 15s, got %q", key, value)
+		return defaultValue
+	}
+	return d
+}
+
+// expiry reads a payment expiry. Midtrans counts expiry in minutes, so the
+// value must be a whole number of them.
+func (v *validator) expiry(key string, defaultValue time.Duration) time.Duration {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	d, err := parseExpiry(value)
+	if err != nil {
+		v.add("%s %v, got %q", key, err, value)
+		return defaultValue
+	}
+	return d
+}
+
+// methodExpiries reads per-method expiries written as
+// method=duration pairs, e.g. "gopay=15m,bca_va=48h".
+func (v *validator) methodExpiries(key string) map[string]time.Duration {
+	value := getEnv(key, "")
+	expiries := make(map[string]time.Duration)
+	if value == "" {
+		return expiries
+	}
+	for _, pair := range strings.Split(value, ",") {
+		method, duration, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		method = strings.TrimSpace(method)
+		if !ok || method == "" {
+			v.add("%s must be a list of method=duration pairs, got %q", key, pair)
+			continue
+		}
+		if !payment.IsMethod(method) {
+			v.add("%s: unknown payment method %q (known: %s)", key, method, strings.Join(payment.Methods, ", "))
+			continue
+		}
+		d, err := parseExpiry(strings.TrimSpace(duration))
+		if err != nil {
+			v.add("%s: %s %v, got %q", key, method, err, duration)
+			continue
+		}
+		expiries[method] = d
+	}
+	return expiries
+}
+
+// rate reads a percentage such as "11" or "0.7%".
+func (v *validator) rate(key string, defaultValue payment.Rate) payment.Rate {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	r, err := payment.ParseRate(value)
+	if err != nil {
+		v.add("%s %v, got %q", key, err, value)
+		return defaultValue
+	}
+	return r
+}
+
+// methodFees reads per-method convenience fees written as method=fee
+// pairs, e.g. "gopay=2%,bca_va=4000,credit_card=2000+2.9%".
+func (v *validator) methodFees(key string) map[string]payment.Fee {
+	value := getEnv(key, "")
+	fees := make(map[string]payment.Fee)
+	if value == "" {
+		return fees
+	}
+	for _, pair := range strings.Split(value, ",") {
+		method, fee, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		method = strings.TrimSpace(method)
+		if !ok || method == "" {
+			v.add("%s must be a list of method=fee pairs, got %q", key, pair)
+			continue
+		}
+		if !payment.IsMethod(method) {
+			v.add("%s: unknown payment method %q (known: %s)", key, method, strings.Join(payment.Methods, ", "))
+			continue
+		}
+		f, err := payment.ParseFee(fee)
+		if err != nil {
+			v.add("%s: %s %v, got %q", key, method, err, fee)
+			continue
+		}
+		fees[method] = f
+	}
+	return fees
+}
+
+func parseExpiry(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < time.Minute || d%time.Minute != 0 {
+		return 0, errors.New("must be a whole number of minutes such as 15m or 24h")
+	}
+	return d, nil
+}
+
+func (v *validator) positiveInt(key string, defaultValue int) int {
+	value := getEnv(key, "")
+	if value == "" {
+		return defaultValue
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		v.add("%s must be a positive integer, got %q", key, value)
+		return defaultValue
+	}
+	return n
+}
+
+func (v *validator) oneOf(key, defaultValue string, allowed ...string) string {
+	value := strings.ToLower(getEnv(key, defaultValue))
+	for _, a := range allowed {
+		if value == a {
+			return value
+		}
+	}
+	v.add("%s must be one of %q, got %q", key, allowed, value)
+	return defaultValue
+}
@@ -0,0 +1,131 @@
+package customer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"payment-service-iae/auth"
+)
+
+var ErrUnavailable = errors.New("customer details unavailable")
+
+// Details is what Midtrans needs to address receipts and run fraud checks.
+type Details struct {
+	FirstName string
+	LastName  string
+	Email     string
+	Phone     string
+}
+
+// FallbackPolicy decides what happens when the token does not carry enough
+// customer information and the user service cannot be reached.
+type FallbackPolicy string
+
+const (
+	// FallbackToken uses whatever the JWT carries, even if incomplete.
+	FallbackToken FallbackPolicy = "token"
+	// FallbackOmit sends no customer details to Midtrans.
+	FallbackOmit FallbackPolicy = "omit"
+	// FallbackReject fails the payment.
+	FallbackReject FallbackPolicy = "reject"
+)
+
+func ParseFallbackPolicy(s string) (FallbackPolicy, error) {
+	switch p := FallbackPolicy(s); p {
+	case FallbackToken, FallbackOmit, FallbackReject:
+		return p, nil
+	case "":
+		return FallbackToken, nil
+	}
+	return "", fmt.Errorf("unknown customer fallback policy %q (want token, omit or reject)", s)
+}
+
+type Lookup struct {
+	users    *UserServiceClient
+	fallback FallbackPolicy
+}
+
+// NewLookup builds a Lookup. users may be nil when no user service is
+// configured, in which case only the token is consulted.
+func NewLookup(users *UserServiceClient, fallback FallbackPolicy) *Lookup {
+	return &Lookup{users: users, fallback: fallback}
+}
+
+// Details returns the customer details for the authenticated principal.
+// A nil result with a nil error means "send no customer details".
+func (l *Lookup) Details(ctx context.Context, p *auth.Principal) (*Details, error) {
+	fromToken := &Details{
+		FirstName: p.FirstName,
+		LastName:  p.LastName,
+		Email:     p.Email,
+		Phone:     p.Phone,
+	}
+	if fromToken.complete() {
+		return fromToken, nil
+	}
+
+	if l.users != nil {
+		d, err := l.users.Get(ctx, p.UserID, p.Token)
+		if err == nil {
+			return d.mergedWith(fromToken), nil
+		}
+		log.Printf("User service lookup for %s failed: %v", p.UserID, err)
+	}
+
+	switch l.fallback {
+	case FallbackOmit:
+		return nil, nil
+	case FallbackReject:
+		return nil, fmt.Errorf("%w for user %s", ErrUnavailable, p.UserID)
+	}
+	if fromToken.Email == "" && fromToken.FirstName == "" {
+		return nil, nil
+	}
+	return fromToken, nil
+}
+
+// DetailsFor returns the details of customerID, whom p pays on behalf of.
+// The token describes p, not the customer, so only the user service is
+// asked; without it no details are sent rather than p's own.
+func (l *Lookup) DetailsFor(ctx context.Context, p *auth.Principal, customerID string) (*Details, error) {
+	if customerID == p.UserID {
+		return l.Details(ctx, p)
+	}
+
+	if l.users != nil {
+		d, err := l.users.Get(ctx, customerID, p.Token)
+		if err == nil {
+			return d, nil
+		}
+		log.Printf("User service lookup for %s on behalf of %s failed: %v", customerID, p.UserID, err)
+	}
+
+	if l.fallback == FallbackReject {
+		return nil, fmt.Errorf("%w for user %s", ErrUnavailable, customerID)
+	}
+	return nil, nil
+}
+
+func (d *Details) complete() bool {
+	return d.FirstName != "" && d.Email != "" && d.Phone != ""
+}
+
+// mergedWith fills empty fields of d from other.
+func (d *Details) mergedWith(other *Details) *Details {
+	merged := *d
+	if merged.FirstName == "" {
+		merged.FirstName = other.FirstName
+	}
+	if merged.LastName == "" {
+		merged.LastName = other.LastName
+	}
+	if merged.Email == "" {
+		merged.Email = other.Email
+	}
+	if merged.Phone == "" {
+		merged.Phone = other.Phone
+	}
+	return &merged
+}
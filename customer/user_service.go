@@ -0,0 +1,66 @@
+package customer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// UserServiceClient reads profiles from the user service at USER_SERVICE_URL.
+type UserServiceClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewUserServiceClient(baseURL string) *UserServiceClient {
+	return &UserServiceClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 3 * time.Second},
+	}
+}
+
+type userResponse struct {
+	ID        string `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Phone     string `json:"phone"`
+}
+
+// Get fetches GET /users/{id}, forwarding the caller's bearer token.
+func (c *UserServiceClient) Get(ctx context.Context, userID, bearerToken string) (*Details, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/users/"+url.PathEscape(userID), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("user service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service: unexpected status %d", resp.StatusCode)
+	}
+
+	var u userResponse
+	if err := json.NewDecoder(resp.Body).Decode(&u); err != nil {
+		return nil, fmt.Errorf("user service: decode response: %w", err)
+	}
+
+	return &Details{
+		FirstName: u.FirstName,
+		LastName:  u.LastName,
+		Email:     u.Email,
+		Phone:     u.Phone,
+	}, nil
+}
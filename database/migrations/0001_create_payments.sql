@@ -0,0 +1,14 @@
+CREATE TABLE payments (
+    order_id     TEXT PRIMARY KEY,
+    book_id      TEXT NOT NULL,
+    customer_id  TEXT NOT NULL,
+    amount       BIGINT NOT NULL CHECK (amount > 0),
+    snap_token   TEXT NOT NULL DEFAULT '',
+    redirect_url TEXT NOT NULL DEFAULT '',
+    status       TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX payments_customer_id_idx ON payments (customer_id);
+CREATE INDEX payments_status_idx ON payments (status);
@@ -0,0 +1,5 @@
+ALTER TABLE payments
+    ADD COLUMN transaction_id TEXT NOT NULL DEFAULT '',
+    ADD COLUMN payment_type   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN fraud_status   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN settled_at     TIMESTAMPTZ;
@@ -0,0 +1,19 @@
+CREATE TABLE payment_items (
+    order_id   TEXT NOT NULL REFERENCES payments (order_id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    book_id    TEXT NOT NULL,
+    title      TEXT NOT NULL,
+    unit_price BIGINT NOT NULL CHECK (unit_price > 0),
+    quantity   INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (order_id, position)
+);
+
+-- Cart payments have several books, so payments.book_id is only set for
+-- single-book payments.
+ALTER TABLE payments ALTER COLUMN book_id SET DEFAULT '';
+
+-- Payments created before carts existed were always one copy of one book.
+INSERT INTO payment_items (order_id, position, book_id, title, unit_price, quantity)
+SELECT order_id, 0, book_id, book_id, amount, 1
+FROM payments
+WHERE book_id <> '';
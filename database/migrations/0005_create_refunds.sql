@@ -0,0 +1,12 @@
+CREATE TABLE refunds (
+    refund_key        TEXT PRIMARY KEY,
+    order_id          TEXT NOT NULL REFERENCES payments (order_id) ON DELETE CASCADE,
+    amount            BIGINT NOT NULL CHECK (amount > 0),
+    reason            TEXT NOT NULL DEFAULT '',
+    status            TEXT NOT NULL,
+    gateway_refund_id TEXT NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX refunds_order_id_idx ON refunds (order_id);
@@ -0,0 +1,15 @@
+CREATE TABLE outbox_events (
+    id              TEXT PRIMARY KEY,
+    seq             BIGINT GENERATED ALWAYS AS IDENTITY,
+    event_type      TEXT NOT NULL,
+    aggregate_id    TEXT NOT NULL,
+    payload         JSONB NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    last_error      TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    published_at    TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_events_unpublished_idx ON outbox_events (aggregate_id, seq)
+    WHERE published_at IS NULL;
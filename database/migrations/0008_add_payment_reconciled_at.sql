@@ -0,0 +1,4 @@
+ALTER TABLE payments ADD COLUMN reconciled_at TIMESTAMPTZ;
+
+CREATE INDEX payments_pending_reconcile_idx ON payments (COALESCE(reconciled_at, created_at))
+    WHERE status = 'pending';
@@ -0,0 +1,4 @@
+ALTER TABLE payments ADD COLUMN expires_at TIMESTAMPTZ;
+
+CREATE INDEX payments_pending_expiry_idx ON payments (expires_at)
+    WHERE status = 'pending' AND expires_at IS NOT NULL;
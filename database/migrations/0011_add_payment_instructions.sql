@@ -0,0 +1 @@
+ALTER TABLE payments ADD COLUMN instructions JSONB;
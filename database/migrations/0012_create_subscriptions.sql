@@ -0,0 +1,29 @@
+CREATE TABLE subscriptions (
+    id              TEXT PRIMARY KEY,
+    plan_id         TEXT NOT NULL,
+    customer_id     TEXT NOT NULL,
+    gateway_id      TEXT NOT NULL,
+    payment_type    TEXT NOT NULL,
+    amount          BIGINT NOT NULL CHECK (amount > 0),
+    interval_count  INTEGER NOT NULL CHECK (interval_count > 0),
+    interval_unit   TEXT NOT NULL,
+    max_interval    INTEGER NOT NULL DEFAULT 0,
+    status          TEXT NOT NULL,
+    next_charge_at  TIMESTAMPTZ,
+    last_charged_at TIMESTAMPTZ,
+    -- Order ID of the last renewal counted, so a repeated notification
+    -- does not advance the cycle twice.
+    last_order_id   TEXT NOT NULL DEFAULT '',
+    charge_count    INTEGER NOT NULL DEFAULT 0,
+    cancelled_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX subscriptions_customer_id_idx ON subscriptions (customer_id);
+-- A customer holds at most one live subscription per plan.
+CREATE UNIQUE INDEX subscriptions_live_plan_idx ON subscriptions (customer_id, plan_id)
+    WHERE status IN ('active', 'paused');
+
+ALTER TABLE payments ADD COLUMN subscription_id TEXT REFERENCES subscriptions (id);
+CREATE INDEX payments_subscription_id_idx ON payments (subscription_id) WHERE subscription_id IS NOT NULL;
@@ -0,0 +1,17 @@
+-- Only the gateway's reusable token and the masked card number are kept;
+-- full card numbers never reach this service.
+CREATE TABLE saved_payment_methods (
+    id               TEXT PRIMARY KEY,
+    customer_id      TEXT NOT NULL,
+    payment_type     TEXT NOT NULL,
+    token            TEXT NOT NULL,
+    token_expires_at TIMESTAMPTZ,
+    masked_card      TEXT NOT NULL,
+    card_type        TEXT NOT NULL DEFAULT '',
+    bank             TEXT NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Saving the same card again replaces its token.
+CREATE UNIQUE INDEX saved_payment_methods_card_idx ON saved_payment_methods (customer_id, masked_card);
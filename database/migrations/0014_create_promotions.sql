@@ -0,0 +1,33 @@
+CREATE TABLE payment_discounts (
+    order_id TEXT NOT NULL REFERENCES payments (order_id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    code     TEXT NOT NULL,
+    name     TEXT NOT NULL,
+    amount   BIGINT NOT NULL CHECK (amount > 0),
+    PRIMARY KEY (order_id, position)
+);
+
+-- Promo usage counters. Redeeming increments them with a conditional
+-- UPDATE, so the row lock keeps concurrent checkouts from going over a cap.
+CREATE TABLE promo_usage (
+    code        TEXT PRIMARY KEY,
+    redemptions INTEGER NOT NULL DEFAULT 0 CHECK (redemptions >= 0)
+);
+
+CREATE TABLE promo_customer_usage (
+    code        TEXT NOT NULL,
+    customer_id TEXT NOT NULL,
+    redemptions INTEGER NOT NULL DEFAULT 0 CHECK (redemptions >= 0),
+    PRIMARY KEY (code, customer_id)
+);
+
+-- Redemptions are taken before the payment is stored, so there is no
+-- foreign key to payments; a payment that ends unpaid releases them.
+CREATE TABLE promo_redemptions (
+    order_id    TEXT NOT NULL,
+    code        TEXT NOT NULL,
+    customer_id TEXT NOT NULL,
+    amount      BIGINT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (order_id, code)
+);
@@ -0,0 +1,5 @@
+-- tax_rate is in basis points: 1100 is 11%.
+ALTER TABLE payments
+    ADD COLUMN tax_rate INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN tax BIGINT NOT NULL DEFAULT 0 CHECK (tax >= 0),
+    ADD COLUMN fee BIGINT NOT NULL DEFAULT 0 CHECK (fee >= 0);
@@ -0,0 +1,8 @@
+-- Amounts are minor units of the row's ISO 4217 currency. Existing rows
+-- were all charged in whole rupiah. A payment's items, discounts and
+-- refunds are in the payment's currency.
+ALTER TABLE payments ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE payments ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE subscriptions ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE subscriptions ALTER COLUMN currency DROP DEFAULT;
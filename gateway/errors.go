@@ -0,0 +1,59 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorKind classifies gateway failures by what the caller can do about them.
+type ErrorKind string
+
+const (
+	KindAuthentication ErrorKind = "GATEWAY_AUTHENTICATION_FAILED"
+	KindValidation     ErrorKind = "GATEWAY_VALIDATION_FAILED"
+	KindDuplicateOrder ErrorKind = "DUPLICATE_ORDER_ID"
+	KindNotFound       ErrorKind = "GATEWAY_TRANSACTION_NOT_FOUND"
+	KindTimeout        ErrorKind = "GATEWAY_TIMEOUT"
+	KindRateLimited    ErrorKind = "GATEWAY_RATE_LIMITED"
+	KindUnavailable    ErrorKind = "GATEWAY_UNAVAILABLE"
+	KindUnknown        ErrorKind = "GATEWAY_ERROR"
+)
+
+// Error is a classified failure from a payment provider. Err keeps the
+// provider's own error reachable through errors.As.
+type Error struct {
+	Provider   string
+	Kind       ErrorKind
+	StatusCode int
+	// Messages are the human-readable reasons the provider gave, if any.
+	Messages []string
+	Err      error
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s %s (status %d)", e.Provider, e.Kind, e.StatusCode)
+	if len(e.Messages) > 0 {
+		msg += ": " + strings.Join(e.Messages, "; ")
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Retryable reports whether sending the same request again may succeed.
+func (e *Error) Retryable() bool {
+	switch e.Kind {
+	case KindTimeout, KindRateLimited, KindUnavailable:
+		return true
+	}
+	return false
+}
+
+// IsKind reports whether err is a gateway *Error of the given kind.
+func IsKind(err error, kind ErrorKind) bool {
+	var gwErr *Error
+	return errors.As(err, &gwErr) && gwErr.Kind == kind
+}
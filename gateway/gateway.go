@@ -0,0 +1,249 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	"payment-service-iae/money"
+)
+
+// Gateway is what the service needs from a payment provider. Each provider
+// (Midtrans today) is an adapter translating these neutral types to its API,
+// so resolvers never depend on a particular vendor. The types are the
+// gateway's own; package payment converts them to and from payments.
+type Gateway interface {
+	// Charge starts a hosted checkout for the order and returns where to
+	// send the customer.
+	Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error)
+	// DirectCharge charges the order through one payment method without a
+	// hosted page and returns the instructions for the customer.
+	DirectCharge(ctx context.Context, req DirectChargeRequest) (*DirectChargeResult, error)
+	// Status reads the provider's current view of the order.
+	Status(ctx context.Context, orderID string) (*TransactionStatus, error)
+	// Cancel stops an order that has not settled yet.
+	Cancel(ctx context.Context, orderID string) (*TransactionStatus, error)
+	// Refund returns money from a settled order. RefundKey makes retries safe.
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+}
+
+// Subscriptions is a provider's recurring billing. The provider charges
+// every cycle on its own; each charge arrives as an ordinary payment
+// notification whose order ID starts with the subscription's Name.
+type Subscriptions interface {
+	CreateSubscription(ctx context.Context, req SubscriptionRequest) (*SubscriptionResult, error)
+	// PauseSubscription stops charging until the subscription is resumed.
+	PauseSubscription(ctx context.Context, id string) error
+	// ResumeSubscription restarts charging and returns the new schedule.
+	ResumeSubscription(ctx context.Context, id string) (*SubscriptionResult, error)
+	// CancelSubscription stops charging for good.
+	CancelSubscription(ctx context.Context, id string) error
+}
+
+// Status is a transaction's state at the provider.
+type Status string
+
+const (
+	StatusPending       Status = "pending"
+	StatusCapture       Status = "capture"
+	StatusSettlement    Status = "settlement"
+	StatusDeny          Status = "deny"
+	StatusCancel        Status = "cancel"
+	StatusExpire        Status = "expire"
+	StatusRefund        Status = "refund"
+	StatusPartialRefund Status = "partial_refund"
+	// StatusFailed is a transaction the provider never accepted.
+	StatusFailed Status = "failed"
+)
+
+type Item struct {
+	ID       string
+	Name     string
+	Price    money.Money
+	Quantity int32
+}
+
+type Customer struct {
+	FirstName string
+	LastName  string
+	Email     string
+	Phone     string
+}
+
+type ChargeRequest struct {
+	OrderID string
+	// Amount must equal the sum of Items when Items are given.
+	Amount   money.Money
+	Items    []Item
+	Customer *Customer
+	// CustomerID is who the provider remembers a saved card for.
+	CustomerID string
+	// Expiry is how long the customer has to pay, counted from the charge.
+	// Zero leaves it to the provider's default.
+	Expiry time.Duration
+	// PaymentMethods limits what the customer is offered, e.g. qris or
+	// bca_va; empty offers everything.
+	PaymentMethods []string
+	// FinishURL is where the customer is sent back to after paying.
+	FinishURL string
+	// CustomFields are passed through to the provider's reports, in order.
+	CustomFields []string
+	CreditCard   *CardOptions
+}
+
+type CardOptions struct {
+	// SaveCard asks for a reusable token for the card.
+	SaveCard    bool
+	Installment *Installment
+}
+
+type Installment struct {
+	// Required makes the customer pay in installments.
+	Required bool
+	// Terms maps a bank to the month counts offered.
+	Terms map[string][]int
+}
+
+type ChargeResult struct {
+	Token       string
+	RedirectURL string
+}
+
+type DirectChargeRequest struct {
+	OrderID string
+	// Amount must equal the sum of Items when Items are given.
+	Amount   money.Money
+	Items    []Item
+	Customer *Customer
+	// Method is a virtual account, qris or an e-wallet, or credit_card to
+	// charge CardToken.
+	Method string
+	// CardToken is a saved card token for one-click card payments.
+	CardToken string
+	Expiry    time.Duration
+	// CallbackURL is where an e-wallet app sends the customer after paying.
+	CallbackURL  string
+	CustomFields []string
+}
+
+type DirectChargeResult struct {
+	TransactionID string
+	Status        Status
+	FraudStatus   string
+	PaymentType   string
+	Instructions  Instructions
+	// ExpiresAt is the provider's deadline for paying, when it reports one.
+	ExpiresAt *time.Time
+	// RedirectURL is set when the card issuer still wants the customer to
+	// authenticate, e.g. 3-D Secure.
+	RedirectURL string
+}
+
+// Instructions tell the customer how to pay a direct charge: a bank and VA
+// number, a QR code, or an e-wallet deeplink.
+type Instructions struct {
+	Method      string
+	Bank        string
+	VANumber    string
+	QRString    string
+	QRImageURL  string
+	DeeplinkURL string
+}
+
+type TransactionStatus struct {
+	OrderID       string
+	TransactionID string
+	Status        Status
+	FraudStatus   string
+	PaymentType   string
+	// GrossAmount is zero when the provider does not report it.
+	GrossAmount money.Money
+	SettledAt   *time.Time
+	// SavedCard is set when the customer asked to save the card they paid
+	// with.
+	SavedCard *SavedCard
+	// Refunds are the refunds made so far, when the provider reports them.
+	Refunds []RefundResult
+}
+
+// Refund is the refund made with refundKey, or nil if there is none.
+func (s *TransactionStatus) Refund(refundKey string) *RefundResult {
+	for i := range s.Refunds {
+		if s.Refunds[i].RefundKey == refundKey {
+			return &s.Refunds[i]
+		}
+	}
+	return nil
+}
+
+// SavedCard is a reusable card token the provider issued with a payment.
+type SavedCard struct {
+	Token      string
+	ExpiresAt  *time.Time
+	MaskedCard string
+	CardType   string
+	Bank       string
+}
+
+type SubscriptionRequest struct {
+	// Name identifies the subscription in renewal order IDs.
+	Name   string
+	Amount money.Money
+	// PaymentType is credit_card or gopay.
+	PaymentType string
+	// Token is the saved card token or GoPay payment option token.
+	Token string
+	// GopayAccountID is the linked GoPay account, required for gopay.
+	GopayAccountID string
+	Interval       int
+	// IntervalUnit is day, week or month.
+	IntervalUnit string
+	// MaxInterval ends the subscription after that many charges; zero
+	// charges until cancelled.
+	MaxInterval int
+	// StartAt is when the first charge runs.
+	StartAt  time.Time
+	Customer *Customer
+}
+
+type SubscriptionResult struct {
+	ID string
+	// NextChargeAt is when the provider will charge next, when it says.
+	NextChargeAt *time.Time
+}
+
+type RefundRequest struct {
+	OrderID   string
+	RefundKey string
+	Amount    money.Money
+	Reason    string
+}
+
+type RefundResult struct {
+	RefundKey string
+	RefundID  string
+	Amount    money.Money
+}
+
+// Total is the sum of the item lines.
+func (r ChargeRequest) Total() (money.Money, error) {
+	return itemsTotal(r.Items)
+}
+
+// Total is the sum of the item lines.
+func (r DirectChargeRequest) Total() (money.Money, error) {
+	return itemsTotal(r.Items)
+}
+
+func itemsTotal(items []Item) (money.Money, error) {
+	var total money.Money
+	for _, item := range items {
+		line, err := item.Price.Mul(int64(item.Quantity))
+		if err != nil {
+			return money.Money{}, err
+		}
+		if total, err = total.Add(line); err != nil {
+			return money.Money{}, err
+		}
+	}
+	return total, nil
+}
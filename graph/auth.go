@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"payment-service-iae/auth"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// getCurrentUser returns the caller authenticated by auth.Middleware, or an
+// UNAUTHENTICATED GraphQL error when the token is missing or invalid.
+func getCurrentUser(ctx context.Context) (*auth.Principal, error) {
+	p, err := auth.FromContext(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+	return p, nil
+}
+
+func unauthenticatedError(err error) *gqlerror.Error {
+	msg := "authentication required"
+	if errors.Is(err, auth.ErrInvalidToken) {
+		msg = "invalid or expired token"
+	}
+	return &gqlerror.Error{
+		Message: msg,
+		Extensions: map[string]interface{}{
+			"code": "UNAUTHENTICATED",
+		},
+	}
+}
+
+func forbiddenError(msg string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: msg,
+		Extensions: map[string]interface{}{
+			"code": "FORBIDDEN",
+		},
+	}
+}
+
+// checkCustomer refuses to create payments owned by anyone but the caller;
+// only admins may pay on a customer's behalf.
+func checkCustomer(user *auth.Principal, customerID string) error {
+	if customerID != user.UserID && !user.HasRole("admin") {
+		return forbiddenError("payments can only be created for the caller")
+	}
+	return nil
+}
+
+// getAdmin is getCurrentUser for admin-only fields; msg explains a refusal.
+func getAdmin(ctx context.Context, msg string) (*auth.Principal, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !user.HasRole("admin") {
+		return nil, forbiddenError(msg)
+	}
+	return user, nil
+}
@@ -0,0 +1,62 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"payment-service-iae/catalog"
+	"payment-service-iae/money"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// priceBook returns the catalogue entry for bookID. The price charged always
+// comes from the catalogue, never from the client.
+func (r *Resolver) priceBook(ctx context.Context, bookID string) (*catalog.Book, error) {
+	book, err := r.catalog.GetBook(ctx, bookID)
+	if errors.Is(err, catalog.ErrBookNotFound) {
+		return nil, &gqlerror.Error{
+			Message: fmt.Sprintf("book %s not found", bookID),
+			Extensions: map[string]interface{}{
+				"code":      "BOOK_NOT_FOUND",
+				"retryable": false,
+			},
+		}
+	}
+	if err != nil {
+		log.Printf("Price lookup for %s failed: %v", bookID, err)
+		return nil, &gqlerror.Error{
+			Message: "book catalogue is unavailable",
+			Extensions: map[string]interface{}{
+				"code":      "CATALOG_UNAVAILABLE",
+				"retryable": true,
+			},
+		}
+	}
+
+	if book.Price.Currency != money.IDR {
+		// Promotions, fees and Midtrans all work in rupiah.
+		log.Printf("Book %s is priced in %s", bookID, book.Price.Currency)
+		return nil, &gqlerror.Error{
+			Message: fmt.Sprintf("book %s is priced in %s; payments can only be made in %s", bookID, book.Price.Currency, money.IDR),
+			Extensions: map[string]interface{}{
+				"code":      "UNSUPPORTED_CURRENCY",
+				"retryable": false,
+			},
+		}
+	}
+
+	return book, nil
+}
+
+func priceMismatchError(clientAmount, expected money.Money) error {
+	return &gqlerror.Error{
+		Message: fmt.Sprintf("amount %s does not match the price %s", clientAmount, expected),
+		Extensions: map[string]interface{}{
+			"code":           "PRICE_MISMATCH",
+			"retryable":      false,
+			"expectedAmount": expected.String(),
+		},
+	}
+}
@@ -0,0 +1,283 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"payment-service-iae/auth"
+	"payment-service-iae/gateway"
+	"payment-service-iae/money"
+	"payment-service-iae/payment"
+	"payment-service-iae/promo"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const maxCheckoutLines = 50
+
+type cartLine struct {
+	bookID   string
+	quantity int32
+}
+
+// checkout prices every line from the catalogue, opens one gateway charge
+// for the total and stores the payment with its line items. expectedTotal,
+// when set, is a client-supplied amount that must match the computed total;
+// options have already been checked by paymentOptions.
+func (r *Resolver) checkout(ctx context.Context, user *auth.Principal, customerID string, lines []cartLine, expectedTotal *money.Money, promoCode string, options *payment.Options) (*payment.Payment, error) {
+	if options != nil && options.CreditCard != nil && options.CreditCard.SaveCard && customerID != user.UserID {
+		// Saved cards are tied to the authenticated user.
+		return nil, forbiddenError("cards can only be saved for the caller")
+	}
+	var methods []string
+	if options != nil {
+		methods = options.PaymentMethods
+	}
+	record, quote, err := r.newPayment(ctx, user, customerID, lines, expectedTotal, promoCode, methods)
+	if err != nil {
+		return nil, err
+	}
+	record.Options = options
+
+	customer, err := r.customerDetails(ctx, user, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	charge := gateway.ChargeRequest{
+		OrderID:    record.OrderID,
+		Amount:     record.Amount,
+		Items:      toGatewayItems(record),
+		Customer:   customer,
+		CustomerID: customerID,
+		FinishURL:  r.finishURL(options),
+	}
+	if options != nil {
+		charge.PaymentMethods = options.PaymentMethods
+		charge.CustomFields = options.CustomFields
+		charge.CreditCard = payment.GatewayCardOptions(options.CreditCard)
+	}
+	expiry := r.expiry.For(charge.PaymentMethods...)
+	charge.Expiry = expiry
+
+	if err := r.redeemPromos(ctx, record, quote); err != nil {
+		return nil, err
+	}
+	resp, err := r.gateway.Charge(ctx, charge)
+	if err != nil {
+		r.recordFailedCharge(ctx, record)
+		return nil, gatewayError(err)
+	}
+
+	record.SnapToken = resp.Token
+	record.RedirectURL = resp.RedirectURL
+	if expiry > 0 {
+		// Counted from after the charge, so the stored expiry is never
+		// earlier than the gateway's.
+		expiresAt := time.Now().Add(expiry)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := r.storePayment(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// newPayment prices the cart, with promotions, PPN and the fee for methods,
+// into a pending payment that has not been charged or stored yet. The
+// quote's discounts still have to be redeemed. Promotions are quoted for
+// customerID, so it is checked against the caller first: promo eligibility
+// and per-customer caps must not follow an ID the client made up.
+func (r *Resolver) newPayment(ctx context.Context, user *auth.Principal, customerID string, lines []cartLine, expectedTotal *money.Money, promoCode string, methods []string) (*payment.Payment, *promo.Quote, error) {
+	if err := checkCustomer(user, customerID); err != nil {
+		return nil, nil, err
+	}
+	lines, err := mergeCartLines(lines)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var items []payment.Item
+	var total money.Money
+	for _, line := range lines {
+		book, err := r.priceBook(ctx, line.bookID)
+		if err != nil {
+			return nil, nil, err
+		}
+		title := book.Title
+		if title == "" {
+			title = book.ID
+		}
+		item := payment.Item{
+			BookID:    book.ID,
+			Title:     title,
+			UnitPrice: book.Price,
+			Quantity:  line.quantity,
+		}
+		subtotal, err := item.UnitPrice.Mul(int64(item.Quantity))
+		if err == nil {
+			total, err = total.Add(subtotal)
+		}
+		if err != nil {
+			return nil, nil, orderTotalError(err)
+		}
+		items = append(items, item)
+	}
+
+	quote, err := r.quoteItems(ctx, customerID, items, promoCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	price, err := r.priceQuote(quote, methods)
+	if err != nil {
+		return nil, nil, orderTotalError(err)
+	}
+	if expectedTotal != nil && *expectedTotal != price.Total {
+		return nil, nil, priceMismatchError(*expectedTotal, price.Total)
+	}
+
+	record := &payment.Payment{
+		OrderID:    newOrderID(customerID, items),
+		CustomerID: customerID,
+		Amount:     price.Total,
+		Status:     payment.StatusPending,
+		Items:      items,
+		Discounts:  toPaymentDiscounts(quote),
+		TaxRate:    price.TaxRate,
+		Tax:        price.Tax,
+		Fee:        price.Fee,
+	}
+	if len(items) == 1 {
+		record.BookID = items[0].BookID
+	}
+	return record, quote, nil
+}
+
+// storePayment stores a charged payment. A payment that cannot be stored
+// never emits the event that would release its promo redemptions, so they
+// are released here instead.
+func (r *Resolver) storePayment(ctx context.Context, record *payment.Payment) error {
+	if err := r.payments.Create(ctx, record); err != nil {
+		r.releasePromos(ctx, record.OrderID)
+		return fmt.Errorf("failed to store payment: %w", err)
+	}
+	return nil
+}
+
+// recordFailedCharge stores a payment the gateway refused, so the attempt
+// is not lost.
+func (r *Resolver) recordFailedCharge(ctx context.Context, record *payment.Payment) {
+	record.Status = payment.StatusFailed
+	if err := r.payments.Create(ctx, record); err != nil {
+		log.Printf("Failed to record failed payment %s: %v", record.OrderID, err)
+		r.releasePromos(ctx, record.OrderID)
+	}
+}
+
+// mergeCartLines validates the cart and folds repeated books into one line,
+// keeping the order in which books first appear.
+func mergeCartLines(lines []cartLine) ([]cartLine, error) {
+	if len(lines) == 0 {
+		return nil, invalidCheckoutError("checkout needs at least one item")
+	}
+	if len(lines) > maxCheckoutLines {
+		return nil, invalidCheckoutError(fmt.Sprintf("checkout supports at most %d items", maxCheckoutLines))
+	}
+
+	var merged []cartLine
+	index := make(map[string]int)
+	for _, line := range lines {
+		if line.bookID == "" {
+			return nil, invalidCheckoutError("bookId must not be empty")
+		}
+		if line.quantity <= 0 {
+			return nil, invalidCheckoutError(fmt.Sprintf("quantity for book %s must be positive", line.bookID))
+		}
+		if i, ok := index[line.bookID]; ok {
+			if merged[i].quantity > math.MaxInt32-line.quantity {
+				return nil, invalidCheckoutError(fmt.Sprintf("quantity for book %s is too large", line.bookID))
+			}
+			merged[i].quantity += line.quantity
+			continue
+		}
+		index[line.bookID] = len(merged)
+		merged = append(merged, line)
+	}
+	return merged, nil
+}
+
+func newOrderID(customerID string, items []payment.Item) string {
+	if len(items) == 1 {
+		return fmt.Sprintf("BOOK-%s-CUST-%s-%d-%s",
+			items[0].BookID,
+			customerID,
+			time.Now().Unix(),
+			uuid.New().String()[0:8])
+	}
+	return fmt.Sprintf("CART-CUST-%s-%d-%s",
+		customerID,
+		time.Now().Unix(),
+		uuid.New().String()[0:8])
+}
+
+// toGatewayItems lists the payment's books, one negative line per discount
+// and then PPN and the fee, so the lines add up to the amount charged.
+func toGatewayItems(p *payment.Payment) []gateway.Item {
+	lines := make([]gateway.Item, 0, len(p.Items)+len(p.Discounts)+2)
+	for _, item := range p.Items {
+		lines = append(lines, gateway.Item{
+			ID:       item.BookID,
+			Name:     item.Title,
+			Price:    item.UnitPrice,
+			Quantity: item.Quantity,
+		})
+	}
+	for _, d := range p.Discounts {
+		lines = append(lines, gateway.Item{
+			ID:       "PROMO-" + d.Code,
+			Name:     d.Name,
+			Price:    d.Amount.Neg(),
+			Quantity: 1,
+		})
+	}
+	if p.Tax.IsPositive() {
+		lines = append(lines, gateway.Item{
+			ID:       "TAX-PPN",
+			Name:     "PPN " + p.TaxRate.String(),
+			Price:    p.Tax,
+			Quantity: 1,
+		})
+	}
+	if p.Fee.IsPositive() {
+		lines = append(lines, gateway.Item{
+			ID:       "FEE",
+			Name:     "Convenience fee",
+			Price:    p.Fee,
+			Quantity: 1,
+		})
+	}
+	return lines
+}
+
+// orderTotalError reports an order whose total does not fit in an amount.
+func orderTotalError(err error) error {
+	if errors.Is(err, money.ErrOverflow) {
+		return invalidCheckoutError("order total is too large")
+	}
+	return err
+}
+
+func invalidCheckoutError(msg string) error {
+	return &gqlerror.Error{
+		Message: msg,
+		Extensions: map[string]interface{}{
+			"code":      "INVALID_CHECKOUT",
+			"retryable": false,
+		},
+	}
+}
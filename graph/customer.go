@@ -0,0 +1,40 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"payment-service-iae/auth"
+	"payment-service-iae/customer"
+	"payment-service-iae/gateway"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// customerDetails resolves the gateway customer block for customerID, who
+// is the caller unless an admin pays on their behalf. It returns nil when
+// there are no details to send.
+func (r *Resolver) customerDetails(ctx context.Context, user *auth.Principal, customerID string) (*gateway.Customer, error) {
+	d, err := r.customers.DetailsFor(ctx, user, customerID)
+	if errors.Is(err, customer.ErrUnavailable) {
+		return nil, &gqlerror.Error{
+			Message: "customer details are temporarily unavailable",
+			Extensions: map[string]interface{}{
+				"code":      "CUSTOMER_LOOKUP_FAILED",
+				"retryable": true,
+			},
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		return nil, nil
+	}
+
+	return &gateway.Customer{
+		FirstName: d.FirstName,
+		LastName:  d.LastName,
+		Email:     d.Email,
+		Phone:     d.Phone,
+	}, nil
+}
@@ -0,0 +1,122 @@
+package graph
+
+import (
+	"context"
+	"payment-service-iae/auth"
+	"payment-service-iae/gateway"
+	"payment-service-iae/graph/model"
+	"payment-service-iae/money"
+	"payment-service-iae/payment"
+	"strings"
+	"time"
+)
+
+// directChargeOptions describes a direct charge as payment options, so the
+// method and callback URL go through the same validation and client policy
+// as createPayment options.
+func (r *Resolver) directChargeOptions(user *auth.Principal, method string, callbackURL *string) (*payment.Options, error) {
+	o := &payment.Options{PaymentMethods: []string{method}}
+	if cb := derefString(callbackURL); cb != "" {
+		if !payment.IsEWallet(method) {
+			return nil, paymentOptionsError(&payment.OptionsError{Problems: []string{
+				"callbackUrl is only used by gopay and shopeepay",
+			}})
+		}
+		o.Callbacks = &payment.Callbacks{FinishURL: cb}
+	}
+	if err := r.checkPaymentOptions(user, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// directCharge prices the cart and charges it through the gateway's direct
+// API with the single method in options.
+func (r *Resolver) directCharge(ctx context.Context, user *auth.Principal, customerID string, lines []cartLine, expectedTotal *money.Money, promoCode string, options *payment.Options) (*payment.Payment, error) {
+	method := options.PaymentMethods[0]
+	record, quote, err := r.newPayment(ctx, user, customerID, lines, expectedTotal, promoCode, []string{method})
+	if err != nil {
+		return nil, err
+	}
+	record.Options = options
+
+	customer, err := r.customerDetails(ctx, user, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	charge := gateway.DirectChargeRequest{
+		OrderID:  record.OrderID,
+		Amount:   record.Amount,
+		Items:    toGatewayItems(record),
+		Customer: customer,
+		Method:   method,
+		Expiry:   r.expiry.For(method),
+	}
+	if options.Callbacks != nil {
+		charge.CallbackURL = options.Callbacks.FinishURL
+	}
+
+	if err := r.redeemPromos(ctx, record, quote); err != nil {
+		return nil, err
+	}
+	resp, err := r.gateway.DirectCharge(ctx, charge)
+	if err != nil {
+		r.recordFailedCharge(ctx, record)
+		return nil, gatewayError(err)
+	}
+
+	record.Status = payment.ResolveStatus(payment.StatusFromGateway(resp.Status), resp.FraudStatus)
+	record.FraudStatus = resp.FraudStatus
+	record.TransactionID = resp.TransactionID
+	record.PaymentType = resp.PaymentType
+	record.Instructions = payment.InstructionsFromGateway(resp.Instructions)
+	record.ExpiresAt = resp.ExpiresAt
+	if record.ExpiresAt == nil && charge.Expiry > 0 {
+		expiresAt := time.Now().Add(charge.Expiry)
+		record.ExpiresAt = &expiresAt
+	}
+	if err := r.storePayment(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func fromDirectPaymentMethod(m model.DirectPaymentMethod) string {
+	return strings.ToLower(string(m))
+}
+
+func toDirectPaymentMethod(method string) model.DirectPaymentMethod {
+	return model.DirectPaymentMethod(strings.ToUpper(method))
+}
+
+func toInstructionsModel(in *payment.Instructions) model.PaymentInstructions {
+	if in == nil {
+		return nil
+	}
+	method := toDirectPaymentMethod(in.Method)
+	switch {
+	case in.Method == "qris":
+		return &model.QrisInstructions{Method: method, QRString: in.QRString, QRImageURL: optionalString(in.QRImageURL)}
+	case payment.IsEWallet(in.Method):
+		return &model.EWalletInstructions{Method: method, DeeplinkURL: in.DeeplinkURL, QRImageURL: optionalString(in.QRImageURL)}
+	default:
+		return &model.VirtualAccountInstructions{Method: method, Bank: in.Bank, VaNumber: in.VANumber}
+	}
+}
+
+func toDirectChargeResponse(p *payment.Payment) *model.DirectChargeResponse {
+	return &model.DirectChargeResponse{
+		OrderID:       p.OrderID,
+		BookID:        p.BookID,
+		CustomerID:    p.CustomerID,
+		Amount:        p.Amount,
+		Discounts:     toPaymentDiscountModels(p.Discounts),
+		Breakdown:     toPriceBreakdownModel(p.Breakdown()),
+		Status:        model.PaymentStatus(strings.ToUpper(string(p.Status))),
+		TransactionID: p.TransactionID,
+		Instructions:  toInstructionsModel(p.Instructions),
+		ExpiresAt:     p.ExpiresAt,
+	}
+}
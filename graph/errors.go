@@ -0,0 +1,57 @@
+package graph
+
+import (
+	"errors"
+	"payment-service-iae/gateway"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// gatewayError turns a payment gateway failure into a GraphQL error the
+// frontend can act on: extensions.code says what went wrong, retryable
+// whether to try again and gatewayStatus is the upstream HTTP status.
+func gatewayError(err error) *gqlerror.Error {
+	var gwErr *gateway.Error
+	if !errors.As(err, &gwErr) {
+		return &gqlerror.Error{
+			Message: "payment gateway request failed",
+			Extensions: map[string]interface{}{
+				"code":      string(gateway.KindUnknown),
+				"retryable": false,
+			},
+		}
+	}
+
+	return &gqlerror.Error{
+		Message: gatewayErrorMessage(gwErr),
+		Extensions: map[string]interface{}{
+			"code":          string(gwErr.Kind),
+			"retryable":     gwErr.Retryable(),
+			"gatewayStatus": gwErr.StatusCode,
+		},
+	}
+}
+
+func gatewayErrorMessage(e *gateway.Error) string {
+	switch e.Kind {
+	case gateway.KindValidation:
+		if len(e.Messages) > 0 {
+			return "payment rejected by gateway: " + e.Messages[0]
+		}
+		return "payment rejected by gateway"
+	case gateway.KindDuplicateOrder:
+		return "order ID has already been used"
+	case gateway.KindAuthentication:
+		// Never echo credential details back to clients.
+		return "payment gateway is misconfigured"
+	case gateway.KindNotFound:
+		return "transaction not found at payment gateway"
+	case gateway.KindTimeout:
+		return "payment gateway timed out"
+	case gateway.KindRateLimited:
+		return "payment gateway is rate limiting requests"
+	case gateway.KindUnavailable:
+		return "payment gateway is unavailable"
+	}
+	return "payment gateway request failed"
+}
@@ -0,0 +1,92 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"payment-service-iae/auth"
+	"payment-service-iae/idempotency"
+	"payment-service-iae/payment"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const idempotencyHeader = "Idempotency-Key"
+
+// requestIdempotencyKey prefers the mutation argument and falls back to the
+// Idempotency-Key HTTP header.
+func requestIdempotencyKey(ctx context.Context, arg *string) string {
+	if arg != nil && *arg != "" {
+		return *arg
+	}
+	if !graphql.HasOperationContext(ctx) {
+		return ""
+	}
+	return graphql.GetOperationContext(ctx).Headers.Get(idempotencyHeader)
+}
+
+// idempotent runs create at most once per (user, key). A repeat with the
+// same requestHash returns the stored result; a repeat with a different hash
+// or while the first request is still running is rejected. Without a key,
+// create simply runs.
+func (r *Resolver) idempotent(ctx context.Context, user *auth.Principal, key, requestHash string, create func() (*payment.Payment, error)) (*payment.Payment, error) {
+	if key == "" {
+		return create()
+	}
+
+	rec, created, err := r.idempotency.Reserve(ctx, user.UserID, key, requestHash)
+	if err != nil && !errors.Is(err, idempotency.ErrInProgress) {
+		return nil, fmt.Errorf("failed to check idempotency key: %w", err)
+	}
+	if err == nil && !created {
+		err = idempotency.Check(rec, requestHash)
+		if err == nil {
+			return r.replayPayment(ctx, rec.OrderID)
+		}
+	}
+	if err != nil {
+		return nil, idempotencyError(err)
+	}
+
+	resp, err := create()
+	if err != nil {
+		if relErr := r.idempotency.Release(ctx, rec); relErr != nil {
+			log.Printf("Failed to release idempotency key %q: %v", key, relErr)
+		}
+		return nil, err
+	}
+
+	if err := r.idempotency.Complete(ctx, rec, resp.OrderID); err != nil {
+		log.Printf("Failed to complete idempotency key %q for %s: %v", key, resp.OrderID, err)
+	}
+	return resp, nil
+}
+
+func (r *Resolver) replayPayment(ctx context.Context, orderID string) (*payment.Payment, error) {
+	p, err := r.payments.Get(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load original payment %s: %w", orderID, err)
+	}
+	return p, nil
+}
+
+func idempotencyError(err error) error {
+	if errors.Is(err, idempotency.ErrConflict) {
+		return &gqlerror.Error{
+			Message: err.Error(),
+			Extensions: map[string]interface{}{
+				"code":      "IDEMPOTENCY_KEY_CONFLICT",
+				"retryable": false,
+			},
+		}
+	}
+	return &gqlerror.Error{
+		Message: err.Error(),
+		Extensions: map[string]interface{}{
+			"code":      "IDEMPOTENCY_KEY_IN_PROGRESS",
+			"retryable": true,
+		},
+	}
+}
@@ -2,16 +2,751 @@
 
 package model
 
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"payment-service-iae/money"
+	"strconv"
+	"time"
+)
+
+// How the customer pays a direct charge; the type depends on the method.
+type PaymentInstructions interface {
+	IsPaymentInstructions()
+}
+
+type CheckoutItemInput struct {
+	BookID   string `json:"bookId"`
+	Quantity int32  `json:"quantity"`
+}
+
+type CheckoutResponse struct {
+	OrderID     string             `json:"orderId"`
+	CustomerID  string             `json:"customerId"`
+	Amount      money.Money        `json:"amount"`
+	Items       []*PaymentItem     `json:"items"`
+	Discounts   []*PaymentDiscount `json:"discounts"`
+	Breakdown   *PriceBreakdown    `json:"breakdown"`
+	Token       string             `json:"token"`
+	RedirectURL string             `json:"redirect_url"`
+	// When the customer must have paid by; the payment expires afterwards.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type CreditCardOptionsInput struct {
+	// Ask Midtrans for a reusable token for the card; once paid the card shows
+	// up in savedPaymentMethods. Only for payments made for the caller.
+	SaveCard    *bool             `json:"saveCard,omitempty"`
+	Installment *InstallmentInput `json:"installment,omitempty"`
+}
+
+type DirectChargeResponse struct {
+	OrderID       string              `json:"orderId"`
+	BookID        string              `json:"bookId"`
+	CustomerID    string              `json:"customerId"`
+	Amount        money.Money         `json:"amount"`
+	Discounts     []*PaymentDiscount  `json:"discounts"`
+	Breakdown     *PriceBreakdown     `json:"breakdown"`
+	Status        PaymentStatus       `json:"status"`
+	TransactionID string              `json:"transactionId"`
+	Instructions  PaymentInstructions `json:"instructions"`
+	// When the customer must have paid by; the payment expires afterwards.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Open the wallet app to pay.
+type EWalletInstructions struct {
+	Method      DirectPaymentMethod `json:"method"`
+	DeeplinkURL string              `json:"deeplinkUrl"`
+	// GoPay only: a QR code to scan with the app from another device.
+	QRImageURL *string `json:"qrImageUrl,omitempty"`
+}
+
+func (EWalletInstructions) IsPaymentInstructions() {}
+
+type InstallmentInput struct {
+	// Only allow paying in installments.
+	Required *bool                   `json:"required,omitempty"`
+	Terms    []*InstallmentTermInput `json:"terms"`
+}
+
+type InstallmentTermInput struct {
+	// Acquiring bank: bni, mandiri, cimb, mega, bca, bri, maybank or offline.
+	Bank   string  `json:"bank"`
+	Months []int32 `json:"months"`
+}
+
 type Mutation struct {
 }
 
+type Payment struct {
+	OrderID string `json:"orderId"`
+	// Set only for single-book payments; see items.
+	BookID     string         `json:"bookId"`
+	CustomerID string         `json:"customerId"`
+	Amount     money.Money    `json:"amount"`
+	Status     PaymentStatus  `json:"status"`
+	Items      []*PaymentItem `json:"items"`
+	// Promotions taken off the items.
+	Discounts []*PaymentDiscount `json:"discounts"`
+	// Itemizes amount: the items' total less discounts, plus PPN and the fee.
+	Breakdown      *PriceBreakdown `json:"breakdown"`
+	Refunds        []*Refund       `json:"refunds"`
+	RefundedAmount money.Money     `json:"refundedAmount"`
+	Token          string          `json:"token"`
+	RedirectURL    string          `json:"redirect_url"`
+	TransactionID  *string         `json:"transactionId,omitempty"`
+	PaymentType    *string         `json:"paymentType,omitempty"`
+	// accept, challenge or deny for card payments. A challenged payment stays PENDING.
+	FraudStatus    *string    `json:"fraudStatus,omitempty"`
+	SettlementTime *time.Time `json:"settlementTime,omitempty"`
+	// When an unpaid payment expires. Null for payments created without an expiry.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// How to pay; set only for direct charges.
+	Instructions PaymentInstructions `json:"instructions,omitempty"`
+	// The subscription this payment renews, if any.
+	SubscriptionID *string   `json:"subscriptionId,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+// Where the customer is sent after paying. URLs must use https.
+type PaymentCallbacksInput struct {
+	FinishURL *string `json:"finishUrl,omitempty"`
+	// Used when the payment was denied, cancelled, expired or failed.
+	ErrorURL *string `json:"errorUrl,omitempty"`
+	// Used while the payment is still waiting, e.g. an unpaid virtual account.
+	PendingURL *string `json:"pendingUrl,omitempty"`
+}
+
+// A promotion taken off the order; sent to Midtrans as a negative line.
+type PaymentDiscount struct {
+	Code   string      `json:"code"`
+	Name   string      `json:"name"`
+	Amount money.Money `json:"amount"`
+}
+
+type PaymentItem struct {
+	BookID    string      `json:"bookId"`
+	Title     string      `json:"title"`
+	UnitPrice money.Money `json:"unitPrice"`
+	Quantity  int32       `json:"quantity"`
+	Subtotal  money.Money `json:"subtotal"`
+}
+
+// Checkout choices passed on to Midtrans Snap. Which of them a client may set
+// is decided by the server's payment options policy; see the README.
+type PaymentOptionsInput struct {
+	// Payment methods offered to the customer, e.g. ["qris", "bca_va"]. Omit to
+	// offer every method enabled for the merchant.
+	EnabledPayments []string               `json:"enabledPayments,omitempty"`
+	Callbacks       *PaymentCallbacksInput `json:"callbacks,omitempty"`
+	// Passed through to Midtrans as custom_field1-3 and shown in its reports.
+	CustomField1 *string                 `json:"customField1,omitempty"`
+	CustomField2 *string                 `json:"customField2,omitempty"`
+	CustomField3 *string                 `json:"customField3,omitempty"`
+	CreditCard   *CreditCardOptionsInput `json:"creditCard,omitempty"`
+}
+
 type PaymentResponse struct {
-	OrderID     string `json:"orderId"`
-	BookID      string `json:"bookId"`
-	CustomerID  string `json:"customerId"`
-	Token       string `json:"token"`
+	OrderID    string `json:"orderId"`
+	BookID     string `json:"bookId"`
+	CustomerID string `json:"customerId"`
+	// What the customer pays, after discounts, tax and fee.
+	Amount    money.Money        `json:"amount"`
+	Discounts []*PaymentDiscount `json:"discounts"`
+	Breakdown *PriceBreakdown    `json:"breakdown"`
+	// Already CAPTURE when a saved card was charged without 3-D Secure.
+	Status PaymentStatus `json:"status"`
+	// Empty when a saved card was charged.
+	Token string `json:"token"`
+	// The Snap page, or for a saved card the 3-D Secure page when the issuer
+	// asks for it; empty when there is nowhere to send the customer.
 	RedirectURL string `json:"redirect_url"`
+	// When the customer must have paid by; the payment expires afterwards.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+type PaymentStatusEvent struct {
+	OrderID        string        `json:"orderId"`
+	Status         PaymentStatus `json:"status"`
+	PreviousStatus PaymentStatus `json:"previousStatus"`
+	Payment        *Payment      `json:"payment"`
+}
+
+// How a payment's amount is made up: subtotal - discount + tax + fee = total.
+// Every amount is in the payment's currency.
+type PriceBreakdown struct {
+	// The items' total before discounts.
+	Subtotal money.Money `json:"subtotal"`
+	Discount money.Money `json:"discount"`
+	// The PPN rate as a percentage, e.g. 11.
+	TaxRate float64 `json:"taxRate"`
+	// PPN on the subtotal less discount, rounded down to the rupiah.
+	Tax money.Money `json:"tax"`
+	// The payment method's convenience fee, rounded up to the rupiah. Not taxed.
+	Fee   money.Money `json:"fee"`
+	Total money.Money `json:"total"`
+}
+
+type PriceQuote struct {
+	BookID string `json:"bookId"`
+	// The catalogue price before discounts.
+	Subtotal  money.Money        `json:"subtotal"`
+	Discounts []*PaymentDiscount `json:"discounts"`
+	// PPN on the subtotal less discounts.
+	Tax money.Money `json:"tax"`
+	Fee money.Money `json:"fee"`
+	// What the customer pays.
+	Total money.Money `json:"total"`
 }
 
+// Scan the QR code with any QRIS-enabled app.
+type QrisInstructions struct {
+	Method DirectPaymentMethod `json:"method"`
+	// Raw QRIS payload, for rendering the code on the device.
+	QRString   string  `json:"qrString"`
+	QRImageURL *string `json:"qrImageUrl,omitempty"`
+}
+
+func (QrisInstructions) IsPaymentInstructions() {}
+
 type Query struct {
 }
+
+type ReadingSubscription struct {
+	ID           string                    `json:"id"`
+	PlanID       string                    `json:"planId"`
+	CustomerID   string                    `json:"customerId"`
+	PaymentType  SubscriptionPaymentType   `json:"paymentType"`
+	Amount       money.Money               `json:"amount"`
+	Interval     int32                     `json:"interval"`
+	IntervalUnit BillingIntervalUnit       `json:"intervalUnit"`
+	MaxInterval  *int32                    `json:"maxInterval,omitempty"`
+	Status       ReadingSubscriptionStatus `json:"status"`
+	// When the next charge runs. Null unless the subscription is active.
+	NextChargeAt  *time.Time `json:"nextChargeAt,omitempty"`
+	LastChargedAt *time.Time `json:"lastChargedAt,omitempty"`
+	// Paid charges so far.
+	ChargeCount int32      `json:"chargeCount"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+}
+
+type Refund struct {
+	RefundKey string       `json:"refundKey"`
+	Amount    money.Money  `json:"amount"`
+	Reason    *string      `json:"reason,omitempty"`
+	Status    RefundStatus `json:"status"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+// A card saved at Midtrans. Only the masked number is kept; the card number
+// and token are never returned.
+type SavedPaymentMethod struct {
+	ID          string `json:"id"`
+	PaymentType string `json:"paymentType"`
+	// First six and last four digits, e.g. 481111-1114.
+	MaskedCard string  `json:"maskedCard"`
+	CardType   *string `json:"cardType,omitempty"`
+	Bank       *string `json:"bank,omitempty"`
+	// When Midtrans stops accepting the token.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Expired   bool       `json:"expired"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type Subscription struct {
+}
+
+type SubscriptionPlan struct {
+	ID    string      `json:"id"`
+	Name  string      `json:"name"`
+	Price money.Money `json:"price"`
+	// Charged every interval intervalUnits.
+	Interval     int32               `json:"interval"`
+	IntervalUnit BillingIntervalUnit `json:"intervalUnit"`
+	// How many times the plan is charged; null runs until cancelled.
+	MaxInterval *int32 `json:"maxInterval,omitempty"`
+}
+
+// Transfer the amount to the virtual account number at the bank.
+type VirtualAccountInstructions struct {
+	Method   DirectPaymentMethod `json:"method"`
+	Bank     string              `json:"bank"`
+	VaNumber string              `json:"vaNumber"`
+}
+
+func (VirtualAccountInstructions) IsPaymentInstructions() {}
+
+type WebhookDelivery struct {
+	ID             string                    `json:"id"`
+	EndpointID     string                    `json:"endpointId"`
+	EventID        string                    `json:"eventId"`
+	EventType      string                    `json:"eventType"`
+	OrderID        string                    `json:"orderId"`
+	Status         WebhookDeliveryStatus     `json:"status"`
+	Attempts       int32                     `json:"attempts"`
+	NextAttemptAt  *time.Time                `json:"nextAttemptAt,omitempty"`
+	DeliveredAt    *time.Time                `json:"deliveredAt,omitempty"`
+	LastStatusCode *int32                    `json:"lastStatusCode,omitempty"`
+	LastError      *string                   `json:"lastError,omitempty"`
+	CreatedAt      time.Time                 `json:"createdAt"`
+	AttemptLog     []*WebhookDeliveryAttempt `json:"attemptLog"`
+}
+
+type WebhookDeliveryAttempt struct {
+	AttemptedAt time.Time `json:"attemptedAt"`
+	// HTTP status of the response, or null when none was received.
+	StatusCode *int32  `json:"statusCode,omitempty"`
+	Error      *string `json:"error,omitempty"`
+	DurationMs int32   `json:"durationMs"`
+}
+
+type WebhookEndpoint struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WebhookEndpointInput struct {
+	// Where events are POSTed; must be an absolute https URL.
+	URL string `json:"url"`
+	// Shared secret for the HMAC signature, at least 16 characters.
+	Secret string `json:"secret"`
+	// Event types to send, e.g. payment.settled. Empty or omitted sends all.
+	Events []string `json:"events,omitempty"`
+}
+
+type WebhookEndpointUpdateInput struct {
+	URL    *string  `json:"url,omitempty"`
+	Secret *string  `json:"secret,omitempty"`
+	Events []string `json:"events,omitempty"`
+	Active *bool    `json:"active,omitempty"`
+}
+
+type BillingIntervalUnit string
+
+const (
+	BillingIntervalUnitDay   BillingIntervalUnit = "DAY"
+	BillingIntervalUnitWeek  BillingIntervalUnit = "WEEK"
+	BillingIntervalUnitMonth BillingIntervalUnit = "MONTH"
+)
+
+var AllBillingIntervalUnit = []BillingIntervalUnit{
+	BillingIntervalUnitDay,
+	BillingIntervalUnitWeek,
+	BillingIntervalUnitMonth,
+}
+
+func (e BillingIntervalUnit) IsValid() bool {
+	switch e {
+	case BillingIntervalUnitDay, BillingIntervalUnitWeek, BillingIntervalUnitMonth:
+		return true
+	}
+	return false
+}
+
+func (e BillingIntervalUnit) String() string {
+	return string(e)
+}
+
+func (e *BillingIntervalUnit) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = BillingIntervalUnit(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid BillingIntervalUnit", str)
+	}
+	return nil
+}
+
+func (e BillingIntervalUnit) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *BillingIntervalUnit) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e BillingIntervalUnit) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+// Payment methods that can be charged without the Snap page.
+type DirectPaymentMethod string
+
+const (
+	DirectPaymentMethodBcaVa     DirectPaymentMethod = "BCA_VA"
+	DirectPaymentMethodBniVa     DirectPaymentMethod = "BNI_VA"
+	DirectPaymentMethodBriVa     DirectPaymentMethod = "BRI_VA"
+	DirectPaymentMethodPermataVa DirectPaymentMethod = "PERMATA_VA"
+	DirectPaymentMethodQRIs      DirectPaymentMethod = "QRIS"
+	DirectPaymentMethodGopay     DirectPaymentMethod = "GOPAY"
+	DirectPaymentMethodShopeepay DirectPaymentMethod = "SHOPEEPAY"
+)
+
+var AllDirectPaymentMethod = []DirectPaymentMethod{
+	DirectPaymentMethodBcaVa,
+	DirectPaymentMethodBniVa,
+	DirectPaymentMethodBriVa,
+	DirectPaymentMethodPermataVa,
+	DirectPaymentMethodQRIs,
+	DirectPaymentMethodGopay,
+	DirectPaymentMethodShopeepay,
+}
+
+func (e DirectPaymentMethod) IsValid() bool {
+	switch e {
+	case DirectPaymentMethodBcaVa, DirectPaymentMethodBniVa, DirectPaymentMethodBriVa, DirectPaymentMethodPermataVa, DirectPaymentMethodQRIs, DirectPaymentMethodGopay, DirectPaymentMethodShopeepay:
+		return true
+	}
+	return false
+}
+
+func (e DirectPaymentMethod) String() string {
+	return string(e)
+}
+
+func (e *DirectPaymentMethod) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = DirectPaymentMethod(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid DirectPaymentMethod", str)
+	}
+	return nil
+}
+
+func (e DirectPaymentMethod) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *DirectPaymentMethod) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e DirectPaymentMethod) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type PaymentStatus string
+
+const (
+	PaymentStatusPending       PaymentStatus = "PENDING"
+	PaymentStatusCapture       PaymentStatus = "CAPTURE"
+	PaymentStatusSettlement    PaymentStatus = "SETTLEMENT"
+	PaymentStatusDeny          PaymentStatus = "DENY"
+	PaymentStatusCancel        PaymentStatus = "CANCEL"
+	PaymentStatusExpire        PaymentStatus = "EXPIRE"
+	PaymentStatusRefund        PaymentStatus = "REFUND"
+	PaymentStatusPartialRefund PaymentStatus = "PARTIAL_REFUND"
+	PaymentStatusFailed        PaymentStatus = "FAILED"
+)
+
+var AllPaymentStatus = []PaymentStatus{
+	PaymentStatusPending,
+	PaymentStatusCapture,
+	PaymentStatusSettlement,
+	PaymentStatusDeny,
+	PaymentStatusCancel,
+	PaymentStatusExpire,
+	PaymentStatusRefund,
+	PaymentStatusPartialRefund,
+	PaymentStatusFailed,
+}
+
+func (e PaymentStatus) IsValid() bool {
+	switch e {
+	case PaymentStatusPending, PaymentStatusCapture, PaymentStatusSettlement, PaymentStatusDeny, PaymentStatusCancel, PaymentStatusExpire, PaymentStatusRefund, PaymentStatusPartialRefund, PaymentStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e PaymentStatus) String() string {
+	return string(e)
+}
+
+func (e *PaymentStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = PaymentStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid PaymentStatus", str)
+	}
+	return nil
+}
+
+func (e PaymentStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *PaymentStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e PaymentStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type ReadingSubscriptionStatus string
+
+const (
+	ReadingSubscriptionStatusActive    ReadingSubscriptionStatus = "ACTIVE"
+	ReadingSubscriptionStatusPaused    ReadingSubscriptionStatus = "PAUSED"
+	ReadingSubscriptionStatusCancelled ReadingSubscriptionStatus = "CANCELLED"
+	// The plan's last charge has been made.
+	ReadingSubscriptionStatusEnded ReadingSubscriptionStatus = "ENDED"
+)
+
+var AllReadingSubscriptionStatus = []ReadingSubscriptionStatus{
+	ReadingSubscriptionStatusActive,
+	ReadingSubscriptionStatusPaused,
+	ReadingSubscriptionStatusCancelled,
+	ReadingSubscriptionStatusEnded,
+}
+
+func (e ReadingSubscriptionStatus) IsValid() bool {
+	switch e {
+	case ReadingSubscriptionStatusActive, ReadingSubscriptionStatusPaused, ReadingSubscriptionStatusCancelled, ReadingSubscriptionStatusEnded:
+		return true
+	}
+	return false
+}
+
+func (e ReadingSubscriptionStatus) String() string {
+	return string(e)
+}
+
+func (e *ReadingSubscriptionStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ReadingSubscriptionStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ReadingSubscriptionStatus", str)
+	}
+	return nil
+}
+
+func (e ReadingSubscriptionStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *ReadingSubscriptionStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e ReadingSubscriptionStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type RefundStatus string
+
+const (
+	RefundStatusPending   RefundStatus = "PENDING"
+	RefundStatusSucceeded RefundStatus = "SUCCEEDED"
+	RefundStatusFailed    RefundStatus = "FAILED"
+)
+
+var AllRefundStatus = []RefundStatus{
+	RefundStatusPending,
+	RefundStatusSucceeded,
+	RefundStatusFailed,
+}
+
+func (e RefundStatus) IsValid() bool {
+	switch e {
+	case RefundStatusPending, RefundStatusSucceeded, RefundStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e RefundStatus) String() string {
+	return string(e)
+}
+
+func (e *RefundStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = RefundStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid RefundStatus", str)
+	}
+	return nil
+}
+
+func (e RefundStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *RefundStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e RefundStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type SubscriptionPaymentType string
+
+const (
+	SubscriptionPaymentTypeCreditCard SubscriptionPaymentType = "CREDIT_CARD"
+	SubscriptionPaymentTypeGopay      SubscriptionPaymentType = "GOPAY"
+)
+
+var AllSubscriptionPaymentType = []SubscriptionPaymentType{
+	SubscriptionPaymentTypeCreditCard,
+	SubscriptionPaymentTypeGopay,
+}
+
+func (e SubscriptionPaymentType) IsValid() bool {
+	switch e {
+	case SubscriptionPaymentTypeCreditCard, SubscriptionPaymentTypeGopay:
+		return true
+	}
+	return false
+}
+
+func (e SubscriptionPaymentType) String() string {
+	return string(e)
+}
+
+func (e *SubscriptionPaymentType) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SubscriptionPaymentType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SubscriptionPaymentType", str)
+	}
+	return nil
+}
+
+func (e SubscriptionPaymentType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *SubscriptionPaymentType) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e SubscriptionPaymentType) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusSucceeded WebhookDeliveryStatus = "SUCCEEDED"
+	WebhookDeliveryStatusFailed    WebhookDeliveryStatus = "FAILED"
+)
+
+var AllWebhookDeliveryStatus = []WebhookDeliveryStatus{
+	WebhookDeliveryStatusPending,
+	WebhookDeliveryStatusSucceeded,
+	WebhookDeliveryStatusFailed,
+}
+
+func (e WebhookDeliveryStatus) IsValid() bool {
+	switch e {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusSucceeded, WebhookDeliveryStatusFailed:
+		return true
+	}
+	return false
+}
+
+func (e WebhookDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalGQL(v any) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalJSON(b []byte) error {
+	s, err := strconv.Unquote(string(b))
+	if err != nil {
+		return err
+	}
+	return e.UnmarshalGQL(s)
+}
+
+func (e WebhookDeliveryStatus) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	e.MarshalGQL(&buf)
+	return buf.Bytes(), nil
+}
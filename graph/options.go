@@ -0,0 +1,133 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"payment-service-iae/auth"
+	"payment-service-iae/graph/model"
+	"payment-service-iae/payment"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// paymentOptions converts the options argument and checks it is well-formed
+// and allowed for the caller's client. No options gives nil.
+func (r *Resolver) paymentOptions(user *auth.Principal, in *model.PaymentOptionsInput) (*payment.Options, error) {
+	o := toPaymentOptions(in)
+	if o == nil {
+		return nil, nil
+	}
+	if err := r.checkPaymentOptions(user, o); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+func (r *Resolver) checkPaymentOptions(user *auth.Principal, o *payment.Options) error {
+	if err := o.Validate(); err != nil {
+		return paymentOptionsError(err)
+	}
+	if cb := o.Callbacks; cb != nil && r.returnURL == "" && (cb.ErrorURL != "" || cb.PendingURL != "") {
+		return paymentOptionsError(&payment.OptionsError{Problems: []string{
+			"errorUrl and pendingUrl need PUBLIC_BASE_URL to be configured; only finishUrl is supported",
+		}})
+	}
+	if err := r.optionsPolicy.Check(user.ClientID, o); err != nil {
+		return paymentOptionsError(err)
+	}
+	return nil
+}
+
+// finishURL is where the gateway sends the customer back to. With
+// PUBLIC_BASE_URL set that is the return endpoint, which picks the finish,
+// error or pending URL from the outcome; otherwise it is finishUrl itself.
+func (r *Resolver) finishURL(o *payment.Options) string {
+	if o == nil || o.Callbacks == nil {
+		return ""
+	}
+	if r.returnURL != "" {
+		return r.returnURL
+	}
+	return o.Callbacks.FinishURL
+}
+
+func toPaymentOptions(in *model.PaymentOptionsInput) *payment.Options {
+	if in == nil {
+		return nil
+	}
+
+	o := &payment.Options{PaymentMethods: in.EnabledPayments}
+	if cb := in.Callbacks; cb != nil {
+		o.Callbacks = &payment.Callbacks{
+			FinishURL:  derefString(cb.FinishURL),
+			ErrorURL:   derefString(cb.ErrorURL),
+			PendingURL: derefString(cb.PendingURL),
+		}
+	}
+
+	fields := []string{derefString(in.CustomField1), derefString(in.CustomField2), derefString(in.CustomField3)}
+	for len(fields) > 0 && fields[len(fields)-1] == "" {
+		fields = fields[:len(fields)-1]
+	}
+	o.CustomFields = fields
+
+	if cc := in.CreditCard; cc != nil {
+		o.CreditCard = &payment.CardOptions{SaveCard: cc.SaveCard != nil && *cc.SaveCard}
+		if in := cc.Installment; in != nil {
+			installment := &payment.Installment{
+				Required: in.Required != nil && *in.Required,
+				Terms:    make(map[string][]int, len(in.Terms)),
+			}
+			for _, t := range in.Terms {
+				bank := strings.ToLower(t.Bank)
+				months := installment.Terms[bank]
+				for _, m := range t.Months {
+					months = append(months, int(m))
+				}
+				installment.Terms[bank] = months
+			}
+			o.CreditCard.Installment = installment
+		}
+	}
+
+	if len(o.PaymentMethods) == 0 && o.Callbacks == nil && len(o.CustomFields) == 0 && o.CreditCard == nil {
+		return nil
+	}
+	return o
+}
+
+// optionsHashParam folds the options into an idempotency request hash.
+func optionsHashParam(o *payment.Options) string {
+	if o == nil {
+		return ""
+	}
+	b, _ := json.Marshal(o)
+	return string(b)
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func paymentOptionsError(err error) error {
+	code := "INVALID_PAYMENT_OPTIONS"
+	if payment.IsOptionsNotAllowed(err) {
+		code = "PAYMENT_OPTIONS_NOT_ALLOWED"
+	}
+	msg := err.Error()
+	var oe *payment.OptionsError
+	if errors.As(err, &oe) {
+		msg = strings.Join(oe.Problems, "; ")
+	}
+	return &gqlerror.Error{
+		Message: msg,
+		Extensions: map[string]interface{}{
+			"code":      code,
+			"retryable": false,
+		},
+	}
+}
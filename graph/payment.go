@@ -0,0 +1,141 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"log"
+	"payment-service-iae/auth"
+	"payment-service-iae/gateway"
+	"payment-service-iae/graph/model"
+	"payment-service-iae/payment"
+	"strings"
+)
+
+// canAccessPayment reports whether user may see p: its customer, or an admin.
+func canAccessPayment(user *auth.Principal, p *payment.Payment) bool {
+	return p.CustomerID == user.UserID || user.HasRole("admin")
+}
+
+// refreshPayment re-reads the transaction from the gateway and applies any
+// change through the payment state machine. A transaction the gateway does
+// not know yet (the customer has not picked a payment method) is left as is.
+func (r *Resolver) refreshPayment(ctx context.Context, p *payment.Payment) (*payment.Payment, error) {
+	status, err := r.gateway.Status(ctx, p.OrderID)
+	if gateway.IsKind(err, gateway.KindNotFound) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+
+	updated, _, err := r.payments.ApplyStatusUpdate(ctx, p.OrderID, payment.UpdateFromGateway(status))
+	if errors.Is(err, payment.ErrInvalidTransition) {
+		log.Printf("Ignored gateway status for %s: %v", p.OrderID, err)
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func toPaymentModel(p *payment.Payment) *model.Payment {
+	return &model.Payment{
+		OrderID:        p.OrderID,
+		BookID:         p.BookID,
+		CustomerID:     p.CustomerID,
+		Amount:         p.Amount,
+		Status:         model.PaymentStatus(strings.ToUpper(string(p.Status))),
+		Items:          toPaymentItemModels(p.Items),
+		Discounts:      toPaymentDiscountModels(p.Discounts),
+		Breakdown:      toPriceBreakdownModel(p.Breakdown()),
+		Refunds:        toRefundModels(p.Refunds),
+		RefundedAmount: p.RefundedAmount(),
+		Token:          p.SnapToken,
+		RedirectURL:    p.RedirectURL,
+		TransactionID:  optionalString(p.TransactionID),
+		PaymentType:    optionalString(p.PaymentType),
+		FraudStatus:    optionalString(p.FraudStatus),
+		SettlementTime: p.SettledAt,
+		ExpiresAt:      p.ExpiresAt,
+		Instructions:   toInstructionsModel(p.Instructions),
+		SubscriptionID: optionalString(p.SubscriptionID),
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+func toPaymentItemModels(items []payment.Item) []*model.PaymentItem {
+	out := make([]*model.PaymentItem, 0, len(items))
+	for _, item := range items {
+		out = append(out, &model.PaymentItem{
+			BookID:    item.BookID,
+			Title:     item.Title,
+			UnitPrice: item.UnitPrice,
+			Quantity:  item.Quantity,
+			Subtotal:  item.Subtotal(),
+		})
+	}
+	return out
+}
+
+func toPriceBreakdownModel(b payment.Breakdown) *model.PriceBreakdown {
+	return &model.PriceBreakdown{
+		Subtotal: b.Subtotal,
+		Discount: b.Discount,
+		TaxRate:  float64(b.TaxRate) / 100,
+		Tax:      b.Tax,
+		Fee:      b.Fee,
+		Total:    b.Total,
+	}
+}
+
+func toRefundModels(refunds []payment.Refund) []*model.Refund {
+	out := make([]*model.Refund, 0, len(refunds))
+	for _, r := range refunds {
+		out = append(out, &model.Refund{
+			RefundKey: r.RefundKey,
+			Amount:    r.Amount,
+			Reason:    optionalString(r.Reason),
+			Status:    model.RefundStatus(strings.ToUpper(string(r.Status))),
+			CreatedAt: r.CreatedAt,
+		})
+	}
+	return out
+}
+
+func toPaymentResponse(p *payment.Payment) *model.PaymentResponse {
+	return &model.PaymentResponse{
+		OrderID:     p.OrderID,
+		BookID:      p.BookID,
+		CustomerID:  p.CustomerID,
+		Amount:      p.Amount,
+		Discounts:   toPaymentDiscountModels(p.Discounts),
+		Breakdown:   toPriceBreakdownModel(p.Breakdown()),
+		Status:      model.PaymentStatus(strings.ToUpper(string(p.Status))),
+		Token:       p.SnapToken,
+		RedirectURL: p.RedirectURL,
+		ExpiresAt:   p.ExpiresAt,
+	}
+}
+
+func toCheckoutResponse(p *payment.Payment) *model.CheckoutResponse {
+	return &model.CheckoutResponse{
+		OrderID:     p.OrderID,
+		CustomerID:  p.CustomerID,
+		Amount:      p.Amount,
+		Items:       toPaymentItemModels(p.Items),
+		Discounts:   toPaymentDiscountModels(p.Discounts),
+		Breakdown:   toPriceBreakdownModel(p.Breakdown()),
+		Token:       p.SnapToken,
+		RedirectURL: p.RedirectURL,
+		ExpiresAt:   p.ExpiresAt,
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,123 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"payment-service-iae/auth"
+	"payment-service-iae/graph/model"
+	"payment-service-iae/money"
+	"payment-service-iae/payment"
+	"payment-service-iae/promo"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// quoteItems prices the priced items for customerID with the automatic
+// promotions and promoCode.
+func (r *Resolver) quoteItems(ctx context.Context, customerID string, items []payment.Item, promoCode string) (*promo.Quote, error) {
+	// Promos are in rupiah, like every price priceBook returns.
+	lines := make([]promo.Line, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, promo.Line{BookID: item.BookID, Subtotal: item.Subtotal().Amount})
+	}
+	q, err := r.promos.Quote(ctx, customerID, lines, promoCode)
+	if err != nil {
+		return nil, promoError(err)
+	}
+	return q, nil
+}
+
+// redeemPromos counts the payment's discounts against their caps. It runs
+// just before the gateway charge; a charge that fails records a failed
+// payment, whose event releases them again.
+func (r *Resolver) redeemPromos(ctx context.Context, record *payment.Payment, q *promo.Quote) error {
+	if err := r.promos.Redeem(ctx, record.OrderID, record.CustomerID, q); err != nil {
+		return promoError(err)
+	}
+	return nil
+}
+
+// releasePromos gives back an order's redemptions when no payment was
+// stored for it.
+func (r *Resolver) releasePromos(ctx context.Context, orderID string) {
+	if err := r.promos.Release(ctx, orderID); err != nil {
+		log.Printf("Failed to release promo redemptions of %s: %v", orderID, err)
+	}
+}
+
+func (r *Resolver) previewPrice(ctx context.Context, user *auth.Principal, bookID, promoCode, method string) (*model.PriceQuote, error) {
+	var methods []string
+	if method != "" {
+		if !payment.IsMethod(method) {
+			return nil, paymentOptionsError(&payment.OptionsError{Problems: []string{
+				fmt.Sprintf("unknown payment method %q", method),
+			}})
+		}
+		methods = []string{method}
+	}
+	book, err := r.priceBook(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	items := []payment.Item{{BookID: book.ID, UnitPrice: book.Price, Quantity: 1}}
+	q, err := r.quoteItems(ctx, user.UserID, items, promoCode)
+	if err != nil {
+		return nil, err
+	}
+	price, err := r.priceQuote(q, methods)
+	if err != nil {
+		return nil, orderTotalError(err)
+	}
+	return &model.PriceQuote{
+		BookID:    book.ID,
+		Subtotal:  price.Subtotal,
+		Discounts: toPaymentDiscountModels(toPaymentDiscounts(q)),
+		Tax:       price.Tax,
+		Fee:       price.Fee,
+		Total:     price.Total,
+	}, nil
+}
+
+// priceQuote adds PPN and the fee for methods to a promo quote.
+func (r *Resolver) priceQuote(q *promo.Quote, methods []string) (payment.Breakdown, error) {
+	return r.pricing.Price(money.Rupiah(q.Subtotal), money.Rupiah(q.Subtotal-q.Total), methods...)
+}
+
+func toPaymentDiscounts(q *promo.Quote) []payment.Discount {
+	var discounts []payment.Discount
+	for _, d := range q.Discounts {
+		discounts = append(discounts, payment.Discount{Code: d.Promo.Code, Name: d.Promo.Name, Amount: money.Rupiah(d.Amount)})
+	}
+	return discounts
+}
+
+func promoError(err error) error {
+	code := ""
+	switch {
+	case errors.Is(err, promo.ErrNotFound):
+		code = "PROMO_CODE_NOT_FOUND"
+	case errors.Is(err, promo.ErrNotApplicable):
+		code = "PROMO_CODE_NOT_APPLICABLE"
+	case errors.Is(err, promo.ErrExhausted):
+		code = "PROMO_CODE_EXHAUSTED"
+	default:
+		return fmt.Errorf("promo lookup failed: %w", err)
+	}
+	return &gqlerror.Error{
+		Message: err.Error(),
+		Extensions: map[string]interface{}{
+			"code":      code,
+			"retryable": false,
+		},
+	}
+}
+
+func toPaymentDiscountModels(discounts []payment.Discount) []*model.PaymentDiscount {
+	out := make([]*model.PaymentDiscount, 0, len(discounts))
+	for _, d := range discounts {
+		out = append(out, &model.PaymentDiscount{Code: d.Code, Name: d.Name, Amount: d.Amount})
+	}
+	return out
+}
@@ -0,0 +1,149 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service-iae/auth"
+	"payment-service-iae/gateway"
+	"payment-service-iae/graph/model"
+	"payment-service-iae/subscription"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// createSubscription signs the caller up for a plan, charging the token
+// the caller saved with the gateway.
+func (r *Resolver) createSubscription(ctx context.Context, user *auth.Principal, planID, paymentType, token, gopayAccountID string) (*subscription.Subscription, error) {
+	customer, err := r.customerDetails(ctx, user, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := r.subscriptions.Create(ctx, subscription.CreateRequest{
+		PlanID:         planID,
+		CustomerID:     user.UserID,
+		PaymentType:    paymentType,
+		Token:          token,
+		GopayAccountID: gopayAccountID,
+		Customer:       customer,
+	})
+	if err != nil {
+		return nil, subscriptionError(err, "")
+	}
+	return s, nil
+}
+
+// findOwnedSubscription returns the subscription if the user may see it:
+// its customer, or an admin. Anything else is nil, so subscription IDs
+// cannot be probed.
+func (r *Resolver) findOwnedSubscription(ctx context.Context, user *auth.Principal, id string) (*subscription.Subscription, error) {
+	s, err := r.subscriptions.Get(ctx, id)
+	if errors.Is(err, subscription.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load subscription: %w", err)
+	}
+	if s.CustomerID != user.UserID && !user.HasRole("admin") {
+		return nil, nil
+	}
+	return s, nil
+}
+
+// changeSubscription checks the caller owns the subscription and applies
+// one of the service's pause, resume or cancel operations to it.
+func (r *Resolver) changeSubscription(ctx context.Context, id string, change func(ctx context.Context, id string) (*subscription.Subscription, error)) (*model.ReadingSubscription, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	owned, err := r.findOwnedSubscription(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	if owned == nil {
+		return nil, subscriptionError(subscription.ErrNotFound, id)
+	}
+	s, err := change(ctx, id)
+	if err != nil {
+		return nil, subscriptionError(err, id)
+	}
+	return toReadingSubscriptionModel(s), nil
+}
+
+// subscriptionError maps subscription service failures to GraphQL errors;
+// gateway failures keep the gateway error codes.
+func subscriptionError(err error, id string) error {
+	var gwErr *gateway.Error
+	code := ""
+	switch {
+	case errors.As(err, &gwErr):
+		return gatewayError(err)
+	case errors.Is(err, subscription.ErrNotFound):
+		return &gqlerror.Error{
+			Message:    fmt.Sprintf("subscription %s not found", id),
+			Extensions: map[string]interface{}{"code": "NOT_FOUND"},
+		}
+	case errors.Is(err, subscription.ErrPlanNotFound):
+		code = "PLAN_NOT_FOUND"
+	case errors.Is(err, subscription.ErrAlreadySubscribed):
+		code = "ALREADY_SUBSCRIBED"
+	case errors.Is(err, subscription.ErrInvalidTransition):
+		code = "SUBSCRIPTION_STATE_CONFLICT"
+	case errors.Is(err, subscription.ErrInvalidRequest):
+		code = "INVALID_SUBSCRIPTION"
+	default:
+		return fmt.Errorf("subscription request failed: %w", err)
+	}
+	return &gqlerror.Error{
+		Message: err.Error(),
+		Extensions: map[string]interface{}{
+			"code":      code,
+			"retryable": false,
+		},
+	}
+}
+
+func fromSubscriptionPaymentType(t model.SubscriptionPaymentType) string {
+	return strings.ToLower(string(t))
+}
+
+func optionalInt32(n int) *int32 {
+	if n == 0 {
+		return nil
+	}
+	v := int32(n)
+	return &v
+}
+
+func toSubscriptionPlanModel(p subscription.Plan) *model.SubscriptionPlan {
+	return &model.SubscriptionPlan{
+		ID:           p.ID,
+		Name:         p.Name,
+		Price:        p.Price,
+		Interval:     int32(p.Interval),
+		IntervalUnit: model.BillingIntervalUnit(strings.ToUpper(p.IntervalUnit)),
+		MaxInterval:  optionalInt32(p.MaxInterval),
+	}
+}
+
+func toReadingSubscriptionModel(s *subscription.Subscription) *model.ReadingSubscription {
+	return &model.ReadingSubscription{
+		ID:            s.ID,
+		PlanID:        s.PlanID,
+		CustomerID:    s.CustomerID,
+		PaymentType:   model.SubscriptionPaymentType(strings.ToUpper(s.PaymentType)),
+		Amount:        s.Amount,
+		Interval:      int32(s.Interval),
+		IntervalUnit:  model.BillingIntervalUnit(strings.ToUpper(s.IntervalUnit)),
+		MaxInterval:   optionalInt32(s.MaxInterval),
+		Status:        model.ReadingSubscriptionStatus(strings.ToUpper(string(s.Status))),
+		NextChargeAt:  s.NextChargeAt,
+		LastChargedAt: s.LastChargedAt,
+		ChargeCount:   int32(s.ChargeCount),
+		CancelledAt:   s.CancelledAt,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+	}
+}
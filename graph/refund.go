@@ -0,0 +1,163 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"payment-service-iae/auth"
+	"payment-service-iae/gateway"
+	"payment-service-iae/money"
+	"payment-service-iae/payment"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// loadOwnedPayment fetches a payment the user may act on. Other customers'
+// payments are reported as not found.
+func (r *Resolver) loadOwnedPayment(ctx context.Context, user *auth.Principal, orderID string) (*payment.Payment, error) {
+	p, err := r.payments.Get(ctx, orderID)
+	if errors.Is(err, payment.ErrNotFound) || (err == nil && !canAccessPayment(user, p)) {
+		return nil, paymentNotFoundError(orderID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payment: %w", err)
+	}
+	return p, nil
+}
+
+// refundPayment reserves the refund locally, asks the gateway to perform it and
+// then records the outcome. The reservation keeps concurrent refunds from
+// exceeding the paid amount. With refundKey, a pending refund is sent again
+// instead; the gateway never refunds the same key twice.
+func (r *Resolver) refundPayment(ctx context.Context, orderID string, amount money.Money, reason, refundKey string) (*payment.Payment, error) {
+	var refund *payment.Refund
+	var err error
+	if refundKey != "" {
+		refund, err = r.payments.PendingRefund(ctx, orderID, refundKey)
+	} else {
+		refund, err = r.payments.ReserveRefund(ctx, orderID, amount, reason)
+	}
+	switch {
+	case errors.Is(err, payment.ErrNotFound):
+		return nil, paymentNotFoundError(orderID)
+	case errors.Is(err, payment.ErrNotRefundable), errors.Is(err, payment.ErrRefundTooLarge),
+		errors.Is(err, payment.ErrRefundNotPending), errors.Is(err, money.ErrCurrencyMismatch):
+		return nil, paymentStateError("REFUND_NOT_ALLOWED", err)
+	case err != nil:
+		return nil, fmt.Errorf("failed to reserve refund: %w", err)
+	}
+
+	result, err := r.gateway.Refund(ctx, gateway.RefundRequest{
+		OrderID:   orderID,
+		RefundKey: refund.RefundKey,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+	})
+	if gateway.IsKind(err, gateway.KindDuplicateOrder) {
+		// The key was used before, so the refund may well have been made.
+		if made := r.madeRefund(ctx, orderID, refund.RefundKey); made != nil {
+			result, err = made, nil
+		}
+	}
+	if err != nil {
+		if !refundRejected(err) {
+			// Midtrans may have refunded before the call failed, so the
+			// amount stays reserved until the refund is retried with its key.
+			log.Printf("Refund %s for %s has an unknown outcome: %v", refund.RefundKey, orderID, err)
+			return nil, refundPendingError(err, refund.RefundKey)
+		}
+		if failErr := r.payments.FailRefund(ctx, orderID, refund.RefundKey); failErr != nil {
+			log.Printf("Failed to release refund %s for %s: %v", refund.RefundKey, orderID, failErr)
+		}
+		return nil, gatewayError(err)
+	}
+
+	p, err := r.payments.CompleteRefund(ctx, orderID, refund.RefundKey, result.RefundID)
+	if err != nil {
+		// The gateway has refunded; only our record is behind. Log loudly so it
+		// can be reconciled rather than retrying the refund.
+		log.Printf("Refund %s for %s succeeded at the gateway but could not be recorded: %v", refund.RefundKey, orderID, err)
+		return nil, fmt.Errorf("refund succeeded but could not be recorded")
+	}
+	return p, nil
+}
+
+// cancelPayment cancels an unsettled payment at the gateway and applies the
+// resulting status.
+func (r *Resolver) cancelPayment(ctx context.Context, p *payment.Payment) (*payment.Payment, error) {
+	if !p.Status.Cancellable() {
+		return nil, paymentStateError("CANCEL_NOT_ALLOWED", fmt.Errorf("payment in status %s cannot be cancelled", p.Status))
+	}
+
+	status, err := r.gateway.Cancel(ctx, p.OrderID)
+	if gateway.IsKind(err, gateway.KindNotFound) {
+		// The customer has not opened a payment method yet, so there is
+		// nothing at the gateway to cancel; the payment will expire instead.
+		return nil, paymentStateError("PAYMENT_NOT_STARTED", fmt.Errorf("payment has not been started at the gateway yet"))
+	}
+	if err != nil {
+		return nil, gatewayError(err)
+	}
+
+	updated, _, err := r.payments.ApplyStatusUpdate(ctx, p.OrderID, payment.UpdateFromGateway(status))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record cancellation: %w", err)
+	}
+	return updated, nil
+}
+
+// madeRefund looks refundKey up among the refunds the gateway has made, or
+// returns nil when it is not there or the lookup fails.
+func (r *Resolver) madeRefund(ctx context.Context, orderID, refundKey string) *gateway.RefundResult {
+	status, err := r.gateway.Status(ctx, orderID)
+	if err != nil {
+		log.Printf("Failed to look up refund %s for %s: %v", refundKey, orderID, err)
+		return nil
+	}
+	return status.Refund(refundKey)
+}
+
+// refundRejected reports whether the gateway definitely did not refund: it
+// answered with a client error other than a timeout, a conflict or rate
+// limiting. A reused refund key is no rejection; that refund may be done.
+func refundRejected(err error) bool {
+	var gwErr *gateway.Error
+	if !errors.As(err, &gwErr) || gwErr.Kind == gateway.KindDuplicateOrder {
+		return false
+	}
+	switch gwErr.StatusCode {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests:
+		return false
+	}
+	return gwErr.StatusCode >= 400 && gwErr.StatusCode < 500
+}
+
+// refundPendingError is gatewayError for a refund that may have gone
+// through; extensions.refundKey is what to retry it with.
+func refundPendingError(err error, refundKey string) error {
+	gqlErr := gatewayError(err)
+	gqlErr.Message = "refund outcome unknown: " + gqlErr.Message
+	gqlErr.Extensions["refundKey"] = refundKey
+	return gqlErr
+}
+
+func paymentNotFoundError(orderID string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: fmt.Sprintf("payment %s not found", orderID),
+		Extensions: map[string]interface{}{
+			"code": "NOT_FOUND",
+		},
+	}
+}
+
+func paymentStateError(code string, err error) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: err.Error(),
+		Extensions: map[string]interface{}{
+			"code":      code,
+			"retryable": false,
+		},
+	}
+}
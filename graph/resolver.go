@@ -1,14 +1,52 @@
 package graph
 
-import "payment-service-iae/midtrans"
+import (
+	"payment-service-iae/catalog"
+	"payment-service-iae/customer"
+	"payment-service-iae/gateway"
+	"payment-service-iae/idempotency"
+	"payment-service-iae/payment"
+	"payment-service-iae/promo"
+	"payment-service-iae/savedmethod"
+	"payment-service-iae/subscription"
+	"payment-service-iae/webhook"
+)
 
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 type Resolver struct {
-	midtransClient *midtrans.Client
+	gateway       gateway.Gateway
+	payments      *payment.Service
+	customers     *customer.Lookup
+	idempotency   idempotency.Store
+	catalog       catalog.Catalog
+	webhooks      webhook.Store
+	expiry        payment.ExpiryPolicy
+	optionsPolicy *payment.OptionsPolicy
+	// returnURL is sent as the gateway finish URL when callbacks are set;
+	// empty without PUBLIC_BASE_URL.
+	returnURL     string
+	subscriptions *subscription.Service
+	savedMethods  savedmethod.Store
+	promos        *promo.Service
+	pricing       payment.PricingPolicy
 }
 
-func NewResolver(midtransClient *midtrans.Client) *Resolver {
-	return &Resolver{midtransClient: midtransClient}
+func NewResolver(paymentGateway gateway.Gateway, payments *payment.Service, customers *customer.Lookup, idempotencyStore idempotency.Store, books catalog.Catalog, webhooks webhook.Store, expiry payment.ExpiryPolicy, optionsPolicy *payment.OptionsPolicy, returnURL string, subscriptions *subscription.Service, savedMethods savedmethod.Store, promos *promo.Service, pricing payment.PricingPolicy) *Resolver {
+	return &Resolver{
+		gateway:       paymentGateway,
+		payments:      payments,
+		customers:     customers,
+		idempotency:   idempotencyStore,
+		catalog:       books,
+		webhooks:      webhooks,
+		expiry:        expiry,
+		optionsPolicy: optionsPolicy,
+		returnURL:     returnURL,
+		subscriptions: subscriptions,
+		savedMethods:  savedMethods,
+		promos:        promos,
+		pricing:       pricing,
+	}
 }
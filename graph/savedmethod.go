@@ -0,0 +1,132 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service-iae/auth"
+	"payment-service-iae/gateway"
+	"payment-service-iae/graph/model"
+	"payment-service-iae/money"
+	"payment-service-iae/payment"
+	"payment-service-iae/savedmethod"
+	"time"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// savedMethodCheck loads the caller's saved method for a one-click payment.
+// Saved cards belong to the authenticated user, so they can only pay for
+// that user and never alongside Snap options.
+func (r *Resolver) savedMethodCheck(ctx context.Context, user *auth.Principal, customerID, id string, options *payment.Options) (*savedmethod.Method, error) {
+	if customerID != user.UserID {
+		return nil, forbiddenError("saved payment methods can only pay for the caller")
+	}
+	if options != nil {
+		return nil, paymentOptionsError(&payment.OptionsError{Problems: []string{
+			"options cannot be combined with savedPaymentMethodId",
+		}})
+	}
+	m, err := r.savedMethods.Get(ctx, id)
+	if errors.Is(err, savedmethod.ErrNotFound) || (err == nil && m.CustomerID != user.UserID) {
+		return nil, savedMethodError(savedmethod.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load saved payment method: %w", err)
+	}
+	if m.Expired(time.Now()) {
+		return nil, savedMethodError(savedmethod.ErrExpired, id)
+	}
+	return m, nil
+}
+
+// chargeSavedMethod prices the cart and charges the saved card through the
+// gateway's direct API, without sending the customer to Snap.
+func (r *Resolver) chargeSavedMethod(ctx context.Context, user *auth.Principal, lines []cartLine, expectedTotal *money.Money, promoCode string, m *savedmethod.Method) (*payment.Payment, error) {
+	record, quote, err := r.newPayment(ctx, user, m.CustomerID, lines, expectedTotal, promoCode, []string{m.PaymentType})
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := r.customerDetails(ctx, user, m.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+
+	charge := gateway.DirectChargeRequest{
+		OrderID:   record.OrderID,
+		Amount:    record.Amount,
+		Items:     toGatewayItems(record),
+		Customer:  customer,
+		Method:    m.PaymentType,
+		CardToken: m.Token,
+		Expiry:    r.expiry.For(m.PaymentType),
+	}
+
+	if err := r.redeemPromos(ctx, record, quote); err != nil {
+		return nil, err
+	}
+	resp, err := r.gateway.DirectCharge(ctx, charge)
+	if err != nil {
+		r.recordFailedCharge(ctx, record)
+		return nil, gatewayError(err)
+	}
+
+	record.Status = payment.ResolveStatus(payment.StatusFromGateway(resp.Status), resp.FraudStatus)
+	record.FraudStatus = resp.FraudStatus
+	record.TransactionID = resp.TransactionID
+	record.PaymentType = resp.PaymentType
+	record.RedirectURL = resp.RedirectURL
+	if !record.Status.IsPaid() {
+		// Still waiting on 3-D Secure or fraud review.
+		record.ExpiresAt = resp.ExpiresAt
+		if record.ExpiresAt == nil && charge.Expiry > 0 {
+			expiresAt := time.Now().Add(charge.Expiry)
+			record.ExpiresAt = &expiresAt
+		}
+	}
+	if err := r.storePayment(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (r *Resolver) deleteSavedMethod(ctx context.Context, user *auth.Principal, id string) error {
+	if err := r.savedMethods.Delete(ctx, user.UserID, id); err != nil {
+		if errors.Is(err, savedmethod.ErrNotFound) {
+			return savedMethodError(err, id)
+		}
+		return fmt.Errorf("failed to delete saved payment method: %w", err)
+	}
+	return nil
+}
+
+func savedMethodError(err error, id string) error {
+	code := "NOT_FOUND"
+	msg := fmt.Sprintf("saved payment method %s not found", id)
+	if errors.Is(err, savedmethod.ErrExpired) {
+		code = "SAVED_PAYMENT_METHOD_EXPIRED"
+		msg = fmt.Sprintf("saved payment method %s has expired", id)
+	}
+	return &gqlerror.Error{
+		Message: msg,
+		Extensions: map[string]interface{}{
+			"code":      code,
+			"retryable": false,
+		},
+	}
+}
+
+func toSavedPaymentMethodModel(m *savedmethod.Method) *model.SavedPaymentMethod {
+	return &model.SavedPaymentMethod{
+		ID:          m.ID,
+		PaymentType: m.PaymentType,
+		MaskedCard:  m.MaskedCard,
+		CardType:    optionalString(m.CardType),
+		Bank:        optionalString(m.Bank),
+		ExpiresAt:   m.TokenExpiresAt,
+		Expired:     m.Expired(time.Now()),
+		CreatedAt:   m.CreatedAt,
+	}
+}
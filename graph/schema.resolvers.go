@@ -6,53 +6,271 @@ package graph
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"payment-service-iae/graph/model"
-	"time"
-
-	"github.com/google/uuid"
-	midtrans "github.com/midtrans/midtrans-go"
+	"payment-service-iae/idempotency"
+	"payment-service-iae/money"
+	"payment-service-iae/payment"
+	"payment-service-iae/webhook"
+	"strconv"
 )
 
 // CreatePayment is the resolver for the createPayment field.
-func (r *mutationResolver) CreatePayment(ctx context.Context, amount int32, bookID string, customerID string) (*model.PaymentResponse, error) {
-	user := getCurrentUser(ctx) // Implement your auth logic
-	if user == nil {
-		return nil, fmt.Errorf("unauthorized")
+func (r *mutationResolver) CreatePayment(ctx context.Context, amount *money.Money, bookID string, customerID string, idempotencyKey *string, options *model.PaymentOptionsInput, savedPaymentMethodID *string, promoCode *string) (*model.PaymentResponse, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCustomer(user, customerID); err != nil {
+		return nil, err
+	}
+
+	amountParam := ""
+	if amount != nil {
+		amountParam = amount.String()
+	}
+
+	opts, err := r.paymentOptions(user, options)
+	if err != nil {
+		return nil, err
+	}
+
+	savedMethodID := derefString(savedPaymentMethodID)
+	code := derefString(promoCode)
+	lines := []cartLine{{bookID: bookID, quantity: 1}}
+
+	key := requestIdempotencyKey(ctx, idempotencyKey)
+	hash := idempotency.Hash("createPayment", amountParam, bookID, customerID, optionsHashParam(opts), savedMethodID, code)
+	p, err := r.idempotent(ctx, user, key, hash, func() (*payment.Payment, error) {
+		if savedPaymentMethodID != nil {
+			m, err := r.savedMethodCheck(ctx, user, customerID, savedMethodID, opts)
+			if err != nil {
+				return nil, err
+			}
+			return r.chargeSavedMethod(ctx, user, lines, amount, code, m)
+		}
+		return r.checkout(ctx, user, customerID, lines, amount, code, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toPaymentResponse(p), nil
+}
+
+// CreateCheckout is the resolver for the createCheckout field.
+func (r *mutationResolver) CreateCheckout(ctx context.Context, items []*model.CheckoutItemInput, idempotencyKey *string, options *model.PaymentOptionsInput, promoCode *string) (*model.CheckoutResponse, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := r.paymentOptions(user, options)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]cartLine, 0, len(items))
+	params := []string{"createCheckout"}
+	for _, item := range items {
+		lines = append(lines, cartLine{bookID: item.BookID, quantity: item.Quantity})
+		params = append(params, item.BookID, strconv.Itoa(int(item.Quantity)))
+	}
+	code := derefString(promoCode)
+	params = append(params, optionsHashParam(opts), code)
+
+	key := requestIdempotencyKey(ctx, idempotencyKey)
+	p, err := r.idempotent(ctx, user, key, idempotency.Hash(params...), func() (*payment.Payment, error) {
+		return r.checkout(ctx, user, user.UserID, lines, nil, code, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toCheckoutResponse(p), nil
+}
+
+// CreateDirectCharge is the resolver for the createDirectCharge field.
+func (r *mutationResolver) CreateDirectCharge(ctx context.Context, bookID string, customerID string, method model.DirectPaymentMethod, amount *money.Money, callbackURL *string, idempotencyKey *string, promoCode *string) (*model.DirectChargeResponse, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCustomer(user, customerID); err != nil {
+		return nil, err
+	}
+
+	amountParam := ""
+	if amount != nil {
+		amountParam = amount.String()
+	}
+
+	opts, err := r.directChargeOptions(user, fromDirectPaymentMethod(method), callbackURL)
+	if err != nil {
+		return nil, err
+	}
+
+	code := derefString(promoCode)
+
+	key := requestIdempotencyKey(ctx, idempotencyKey)
+	hash := idempotency.Hash("createDirectCharge", amountParam, bookID, customerID, optionsHashParam(opts), code)
+	p, err := r.idempotent(ctx, user, key, hash, func() (*payment.Payment, error) {
+		return r.directCharge(ctx, user, customerID, []cartLine{{bookID: bookID, quantity: 1}}, amount, code, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return toDirectChargeResponse(p), nil
+}
+
+// RefundPayment is the resolver for the refundPayment field.
+func (r *mutationResolver) RefundPayment(ctx context.Context, orderID string, amount *money.Money, reason *string, refundKey *string) (*model.Payment, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !user.HasRole("admin") {
+		return nil, forbiddenError("only admins can refund payments")
+	}
+
+	var refundAmount money.Money
+	if amount != nil {
+		if !amount.IsPositive() {
+			return nil, paymentStateError("REFUND_NOT_ALLOWED", fmt.Errorf("refund amount must be positive"))
+		}
+		refundAmount = *amount
+	}
+	refundReason := ""
+	if reason != nil {
+		refundReason = *reason
+	}
+	key := derefString(refundKey)
+	if key != "" && amount != nil {
+		return nil, paymentStateError("REFUND_NOT_ALLOWED", fmt.Errorf("amount cannot be changed when retrying refund %s", key))
+	}
+
+	p, err := r.refundPayment(ctx, orderID, refundAmount, refundReason, key)
+	if err != nil {
+		return nil, err
+	}
+	return toPaymentModel(p), nil
+}
+
+// CancelPayment is the resolver for the cancelPayment field.
+func (r *mutationResolver) CancelPayment(ctx context.Context, orderID string) (*model.Payment, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := r.loadOwnedPayment(ctx, user, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err = r.cancelPayment(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return toPaymentModel(p), nil
+}
+
+// DeleteSavedPaymentMethod is the resolver for the deleteSavedPaymentMethod field.
+func (r *mutationResolver) DeleteSavedPaymentMethod(ctx context.Context, id string) (bool, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return false, err
+	}
+	if err := r.deleteSavedMethod(ctx, user, id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CreateWebhookEndpoint is the resolver for the createWebhookEndpoint field.
+func (r *mutationResolver) CreateWebhookEndpoint(ctx context.Context, input model.WebhookEndpointInput) (*model.WebhookEndpoint, error) {
+	if _, err := getAdmin(ctx, "only admins can manage webhooks"); err != nil {
+		return nil, err
+	}
+
+	e, err := r.createWebhookEndpoint(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookEndpointModel(e), nil
+}
+
+// UpdateWebhookEndpoint is the resolver for the updateWebhookEndpoint field.
+func (r *mutationResolver) UpdateWebhookEndpoint(ctx context.Context, id string, input model.WebhookEndpointUpdateInput) (*model.WebhookEndpoint, error) {
+	if _, err := getAdmin(ctx, "only admins can manage webhooks"); err != nil {
+		return nil, err
+	}
+
+	e, err := r.updateWebhookEndpoint(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	return toWebhookEndpointModel(e), nil
+}
+
+// DeleteWebhookEndpoint is the resolver for the deleteWebhookEndpoint field.
+func (r *mutationResolver) DeleteWebhookEndpoint(ctx context.Context, id string) (bool, error) {
+	if _, err := getAdmin(ctx, "only admins can manage webhooks"); err != nil {
+		return false, err
+	}
+
+	err := r.webhooks.DeleteEndpoint(ctx, id)
+	if errors.Is(err, webhook.ErrEndpointNotFound) {
+		return false, webhookNotFoundError("webhook endpoint", id)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to delete webhook endpoint: %w", err)
 	}
+	return true, nil
+}
 
-	// Generate unique order ID
-	orderID := fmt.Sprintf("BOOK-%s-CUST-%s-%d-%s",
-		bookID,
-		customerID,
-		time.Now().Unix(),
-		uuid.New().String()[0:8])
+// ReplayWebhookDelivery is the resolver for the replayWebhookDelivery field.
+func (r *mutationResolver) ReplayWebhookDelivery(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	if _, err := getAdmin(ctx, "only admins can manage webhooks"); err != nil {
+		return nil, err
+	}
 
-	// Prepare customer data
-	customer := &midtrans.CustomerDetails{
-		FName: "Test",
-		LName: "User",
-		Email: "test@example.com",
-		Phone: "08123456789",
+	d, err := r.webhooks.Replay(ctx, id)
+	if errors.Is(err, webhook.ErrDeliveryNotFound) {
+		return nil, webhookNotFoundError("webhook delivery", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay webhook delivery: %w", err)
 	}
+	return toWebhookDeliveryModel(d), nil
+}
 
-	resp, _ := r.midtransClient.CreateTransaction(
-		orderID,
-		int64(amount),
-		customer,
-	)
+// CreateSubscription is the resolver for the createSubscription field.
+func (r *mutationResolver) CreateSubscription(ctx context.Context, planID string, paymentType model.SubscriptionPaymentType, token string, gopayAccountID *string) (*model.ReadingSubscription, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	if resp == nil {
-		return nil, fmt.Errorf("payment failed: empty response from payment gateway")
+	s, err := r.createSubscription(ctx, user, planID, fromSubscriptionPaymentType(paymentType), token, derefString(gopayAccountID))
+	if err != nil {
+		return nil, err
 	}
+	return toReadingSubscriptionModel(s), nil
+}
+
+// PauseSubscription is the resolver for the pauseSubscription field.
+func (r *mutationResolver) PauseSubscription(ctx context.Context, id string) (*model.ReadingSubscription, error) {
+	return r.changeSubscription(ctx, id, r.subscriptions.Pause)
+}
 
-	return &model.PaymentResponse{
-		OrderID:     orderID,
-		BookID:      bookID,
-		CustomerID:  customerID,
-		Token:       resp.Token,
-		RedirectURL: resp.RedirectURL,
-	}, nil
+// ResumeSubscription is the resolver for the resumeSubscription field.
+func (r *mutationResolver) ResumeSubscription(ctx context.Context, id string) (*model.ReadingSubscription, error) {
+	return r.changeSubscription(ctx, id, r.subscriptions.Resume)
+}
+
+// CancelSubscription is the resolver for the cancelSubscription field.
+func (r *mutationResolver) CancelSubscription(ctx context.Context, id string) (*model.ReadingSubscription, error) {
+	return r.changeSubscription(ctx, id, r.subscriptions.Cancel)
 }
 
 // HealthCheck is the resolver for the healthCheck field.
@@ -60,37 +278,158 @@ func (r *queryResolver) HealthCheck(ctx context.Context) (string, error) {
 	return "OK", nil
 }
 
-// Mutation returns MutationResolver implementation.
-func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+// Payment is the resolver for the payment field.
+func (r *queryResolver) Payment(ctx context.Context, orderID string, refresh *bool) (*model.Payment, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-// Query returns QueryResolver implementation.
-func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+	p, err := r.payments.Get(ctx, orderID)
+	if errors.Is(err, payment.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load payment: %w", err)
+	}
+	// Report other customers' payments as missing rather than forbidden so
+	// order IDs cannot be probed.
+	if !canAccessPayment(user, p) {
+		return nil, nil
+	}
 
-type mutationResolver struct{ *Resolver }
-type queryResolver struct{ *Resolver }
+	if refresh != nil && *refresh {
+		p, err = r.refreshPayment(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return toPaymentModel(p), nil
+}
+
+// WebhookEndpoints is the resolver for the webhookEndpoints field.
+func (r *queryResolver) WebhookEndpoints(ctx context.Context) ([]*model.WebhookEndpoint, error) {
+	if _, err := getAdmin(ctx, "only admins can view webhooks"); err != nil {
+		return nil, err
+	}
+
+	endpoints, err := r.webhooks.ListEndpoints(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook endpoints: %w", err)
+	}
+	out := make([]*model.WebhookEndpoint, 0, len(endpoints))
+	for i := range endpoints {
+		out = append(out, toWebhookEndpointModel(&endpoints[i]))
+	}
+	return out, nil
+}
+
+// WebhookDeliveries is the resolver for the webhookDeliveries field.
+func (r *queryResolver) WebhookDeliveries(ctx context.Context, endpointID *string, orderID *string, status *model.WebhookDeliveryStatus, limit *int32) ([]*model.WebhookDelivery, error) {
+	if _, err := getAdmin(ctx, "only admins can view webhooks"); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := r.webhookDeliveries(ctx, endpointID, orderID, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*model.WebhookDelivery, 0, len(deliveries))
+	for i := range deliveries {
+		out = append(out, toWebhookDeliveryModel(&deliveries[i]))
+	}
+	return out, nil
+}
 
-// !!! WARNING !!!
-// The code below was going to be deleted when updating resolvers. It has been copied here so you have
-// one last chance to move it out of harms way if you want. There are two reasons this happens:
-//  - When renaming or deleting a resolver the old code will be put in here. You can safely delete
-//    it when you're done.
-//  - You have helper methods in this file. Move them out to keep these resolver files clean.
+// SubscriptionPlans is the resolver for the subscriptionPlans field.
+func (r *queryResolver) SubscriptionPlans(ctx context.Context) ([]*model.SubscriptionPlan, error) {
+	plans := r.subscriptions.Plans()
+	out := make([]*model.SubscriptionPlan, 0, len(plans))
+	for _, p := range plans {
+		out = append(out, toSubscriptionPlanModel(p))
+	}
+	return out, nil
+}
 
-func getCurrentUser(ctx context.Context) *User {
-	// In real implementation, get user from context/JWT
-	return &User{
-		ID:        "user-123",
-		FirstName: "Budi",
-		LastName:  "Pratama",
-		Email:     "budi.pra@example.com",
-		Phone:     "08111222333",
+// ReadingSubscriptions is the resolver for the readingSubscriptions field.
+func (r *queryResolver) ReadingSubscriptions(ctx context.Context) ([]*model.ReadingSubscription, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
 	}
+
+	subs, err := r.subscriptions.ListByCustomer(ctx, user.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+	out := make([]*model.ReadingSubscription, 0, len(subs))
+	for i := range subs {
+		out = append(out, toReadingSubscriptionModel(&subs[i]))
+	}
+	return out, nil
 }
 
-type User struct {
-	ID        string
-	FirstName string
-	LastName  string
-	Email     string
-	Phone     string
+// ReadingSubscription is the resolver for the readingSubscription field.
+func (r *queryResolver) ReadingSubscription(ctx context.Context, id string) (*model.ReadingSubscription, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := r.findOwnedSubscription(ctx, user, id)
+	if err != nil || s == nil {
+		return nil, err
+	}
+	return toReadingSubscriptionModel(s), nil
+}
+
+// SavedPaymentMethods is the resolver for the savedPaymentMethods field.
+func (r *queryResolver) SavedPaymentMethods(ctx context.Context) ([]*model.SavedPaymentMethod, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	methods, err := r.savedMethods.ListByCustomer(ctx, user.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved payment methods: %w", err)
+	}
+	out := make([]*model.SavedPaymentMethod, 0, len(methods))
+	for i := range methods {
+		out = append(out, toSavedPaymentMethodModel(&methods[i]))
+	}
+	return out, nil
 }
+
+// PreviewPrice is the resolver for the previewPrice field.
+func (r *queryResolver) PreviewPrice(ctx context.Context, bookID string, promoCode *string, paymentMethod *string) (*model.PriceQuote, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return r.previewPrice(ctx, user, bookID, derefString(promoCode), derefString(paymentMethod))
+}
+
+// PaymentStatusChanged is the resolver for the paymentStatusChanged field.
+func (r *subscriptionResolver) PaymentStatusChanged(ctx context.Context, orderID string) (<-chan *model.PaymentStatusEvent, error) {
+	user, err := getCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.watchPaymentStatus(ctx, user, orderID)
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
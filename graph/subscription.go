@@ -0,0 +1,57 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"payment-service-iae/auth"
+	"payment-service-iae/graph/model"
+	"payment-service-iae/payment"
+	"strings"
+)
+
+// watchPaymentStatus streams status changes of the user's own payment until
+// the subscription ends or the user's token expires. Watching starts before
+// the ownership check so no change can slip in between the two.
+func (r *Resolver) watchPaymentStatus(ctx context.Context, user *auth.Principal, orderID string) (<-chan *model.PaymentStatusEvent, error) {
+	var cancel context.CancelFunc
+	if user.ExpiresAt.IsZero() {
+		ctx, cancel = context.WithCancel(ctx)
+	} else {
+		ctx, cancel = context.WithDeadline(ctx, user.ExpiresAt)
+	}
+	changes := r.payments.WatchStatus(ctx, orderID)
+
+	p, err := r.payments.Get(ctx, orderID)
+	if errors.Is(err, payment.ErrNotFound) || (err == nil && p.CustomerID != user.UserID) {
+		cancel()
+		return nil, paymentNotFoundError(orderID)
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to load payment: %w", err)
+	}
+
+	events := make(chan *model.PaymentStatusEvent, 1)
+	go func() {
+		defer cancel()
+		defer close(events)
+		for change := range changes {
+			select {
+			case events <- toPaymentStatusEvent(change):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+func toPaymentStatusEvent(c payment.StatusChange) *model.PaymentStatusEvent {
+	return &model.PaymentStatusEvent{
+		OrderID:        c.Payment.OrderID,
+		Status:         model.PaymentStatus(strings.ToUpper(string(c.Payment.Status))),
+		PreviousStatus: model.PaymentStatus(strings.ToUpper(string(c.Previous))),
+		Payment:        toPaymentModel(c.Payment),
+	}
+}
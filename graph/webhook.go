@@ -0,0 +1,177 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"payment-service-iae/graph/model"
+	"payment-service-iae/payment"
+	"payment-service-iae/webhook"
+	"slices"
+	"strings"
+
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	minWebhookSecretLength = 16
+	maxWebhookDeliveries   = 200
+)
+
+func (r *Resolver) createWebhookEndpoint(ctx context.Context, input model.WebhookEndpointInput) (*webhook.Endpoint, error) {
+	e := &webhook.Endpoint{Active: true}
+	if err := applyWebhookSettings(e, &input.URL, &input.Secret, input.Events); err != nil {
+		return nil, err
+	}
+	if err := r.webhooks.CreateEndpoint(ctx, e); err != nil {
+		return nil, fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+	return e, nil
+}
+
+func (r *Resolver) updateWebhookEndpoint(ctx context.Context, id string, input model.WebhookEndpointUpdateInput) (*webhook.Endpoint, error) {
+	e, err := r.webhooks.UpdateEndpoint(ctx, id, func(e *webhook.Endpoint) error {
+		if input.Active != nil {
+			e.Active = *input.Active
+		}
+		return applyWebhookSettings(e, input.URL, input.Secret, input.Events)
+	})
+	if errors.Is(err, webhook.ErrEndpointNotFound) {
+		return nil, webhookNotFoundError("webhook endpoint", id)
+	}
+	if err != nil {
+		var gqlErr *gqlerror.Error
+		if errors.As(err, &gqlErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	return e, nil
+}
+
+// applyWebhookSettings validates and sets the given fields; nil leaves a
+// field unchanged.
+func applyWebhookSettings(e *webhook.Endpoint, rawURL, secret *string, events []string) error {
+	if rawURL != nil {
+		u, err := url.Parse(*rawURL)
+		// Events and their signatures must not travel in cleartext.
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return invalidWebhookError(fmt.Sprintf("url must be an absolute https URL, got %q", *rawURL))
+		}
+		e.URL = u.String()
+	}
+	if secret != nil {
+		if len(*secret) < minWebhookSecretLength {
+			return invalidWebhookError(fmt.Sprintf("secret must be at least %d characters", minWebhookSecretLength))
+		}
+		e.Secret = *secret
+	}
+	if events != nil {
+		var types []string
+		for _, t := range events {
+			t = strings.TrimSpace(t)
+			if !slices.Contains(payment.EventTypes, t) {
+				return invalidWebhookError(fmt.Sprintf("unknown event type %q (known: %s)", t, strings.Join(payment.EventTypes, ", ")))
+			}
+			if !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+		e.EventTypes = types
+	}
+	return nil
+}
+
+func (r *Resolver) webhookDeliveries(ctx context.Context, endpointID, orderID *string, status *model.WebhookDeliveryStatus, limit *int32) ([]webhook.Delivery, error) {
+	f := webhook.DeliveryFilter{Limit: 50}
+	if endpointID != nil {
+		f.EndpointID = *endpointID
+	}
+	if orderID != nil {
+		f.OrderID = *orderID
+	}
+	if status != nil {
+		f.Status = webhook.DeliveryStatus(strings.ToLower(string(*status)))
+	}
+	if limit != nil {
+		if *limit < 1 || *limit > maxWebhookDeliveries {
+			return nil, invalidWebhookError(fmt.Sprintf("limit must be between 1 and %d", maxWebhookDeliveries))
+		}
+		f.Limit = int(*limit)
+	}
+
+	deliveries, err := r.webhooks.ListDeliveries(ctx, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+func toWebhookEndpointModel(e *webhook.Endpoint) *model.WebhookEndpoint {
+	events := e.EventTypes
+	if events == nil {
+		events = []string{}
+	}
+	return &model.WebhookEndpoint{
+		ID:        e.ID,
+		URL:       e.URL,
+		Events:    events,
+		Active:    e.Active,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.UpdatedAt,
+	}
+}
+
+func toWebhookDeliveryModel(d *webhook.Delivery) *model.WebhookDelivery {
+	m := &model.WebhookDelivery{
+		ID:            d.ID,
+		EndpointID:    d.EndpointID,
+		EventID:       d.EventID,
+		EventType:     d.EventType,
+		OrderID:       d.OrderID,
+		Status:        model.WebhookDeliveryStatus(strings.ToUpper(string(d.Status))),
+		Attempts:      int32(d.Attempts),
+		NextAttemptAt: d.NextAttemptAt,
+		DeliveredAt:   d.DeliveredAt,
+		LastError:     optionalString(d.LastError),
+		CreatedAt:     d.CreatedAt,
+		AttemptLog:    make([]*model.WebhookDeliveryAttempt, 0, len(d.Log)),
+	}
+	if d.LastStatusCode != 0 {
+		code := int32(d.LastStatusCode)
+		m.LastStatusCode = &code
+	}
+	for _, a := range d.Log {
+		attempt := &model.WebhookDeliveryAttempt{
+			AttemptedAt: a.AttemptedAt,
+			Error:       optionalString(a.Error),
+			DurationMs:  int32(a.Duration.Milliseconds()),
+		}
+		if a.StatusCode != 0 {
+			code := int32(a.StatusCode)
+			attempt.StatusCode = &code
+		}
+		m.AttemptLog = append(m.AttemptLog, attempt)
+	}
+	return m
+}
+
+func webhookNotFoundError(what, id string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: fmt.Sprintf("%s %s not found", what, id),
+		Extensions: map[string]interface{}{
+			"code": "NOT_FOUND",
+		},
+	}
+}
+
+func invalidWebhookError(msg string) *gqlerror.Error {
+	return &gqlerror.Error{
+		Message: msg,
+		Extensions: map[string]interface{}{
+			"code":      "INVALID_WEBHOOK",
+			"retryable": false,
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// TTL is how long a key is remembered. After that the same key may be reused
+// for a new request.
+const TTL = 24 * time.Hour
+
+// Lease is how long a request holds a key before completing it. A key still
+// in progress after that belongs to a request that crashed, so a retry takes
+// it over. It outlasts the gateway's 80s HTTP timeout.
+const Lease = 2 * time.Minute
+
+var (
+	// ErrConflict means the key was already used with different parameters.
+	ErrConflict = errors.New("idempotency key reused with different parameters")
+	// ErrInProgress means another request holding the key has not finished.
+	ErrInProgress = errors.New("request with this idempotency key is still in progress")
+	// ErrTakenOver means a retry took the key over after the reservation's
+	// lease ran out.
+	ErrTakenOver = errors.New("idempotency key was taken over by a retry")
+)
+
+type Record struct {
+	Scope       string
+	Key         string
+	RequestHash string
+	// OrderID is empty until the original request completes.
+	OrderID string
+	// CreatedAt is when the key was reserved. It tells a reservation apart
+	// from a later takeover of the same key.
+	CreatedAt time.Time
+}
+
+// expired reports whether the key may be reserved again: it is past its
+// TTL, or still in progress after its lease.
+func (r *Record) expired() bool {
+	age := time.Since(r.CreatedAt)
+	return age >= TTL || (r.OrderID == "" && age >= Lease)
+}
+
+// Store tracks idempotency keys per scope (the authenticated user), so two
+// customers choosing the same key never collide.
+type Store interface {
+	// Reserve claims key for a new request, taking over keys past their TTL
+	// and in-progress keys past their Lease. If the key is already held it
+	// returns the existing record with created=false instead.
+	Reserve(ctx context.Context, scope, key, requestHash string) (rec *Record, created bool, err error)
+	// Complete records the order the request holding rec produced. It
+	// returns ErrTakenOver if a retry has taken the key over since.
+	Complete(ctx context.Context, rec *Record, orderID string) error
+	// Release forgets a reservation whose request failed, so it can be
+	// retried. A key taken over since is left to the retry holding it.
+	Release(ctx context.Context, rec *Record) error
+}
+
+// Hash fingerprints request parameters so a reused key can be checked
+// against the request that first used it.
+func Hash(params ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(params, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Check decides what a request should do with the record Reserve returned
+// when the key already existed.
+func Check(rec *Record, requestHash string) error {
+	if rec.RequestHash != requestHash {
+		return ErrConflict
+	}
+	if rec.OrderID == "" {
+		return ErrInProgress
+	}
+	return nil
+}
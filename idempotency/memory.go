@@ -0,0 +1,61 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store for tests and local development.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[[2]string]Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[[2]string]Record)}
+}
+
+func (s *MemoryStore) Reserve(ctx context.Context, scope, key, requestHash string) (*Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := [2]string{scope, key}
+	if rec, ok := s.records[id]; ok && !rec.expired() {
+		return &rec, false, nil
+	}
+
+	rec := Record{Scope: scope, Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	s.records[id] = rec
+	return &rec, true, nil
+}
+
+func (s *MemoryStore) Complete(ctx context.Context, rec *Record, orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := [2]string{rec.Scope, rec.Key}
+	stored, ok := s.records[id]
+	if !ok || !stored.held(rec) {
+		return ErrTakenOver
+	}
+	stored.OrderID = orderID
+	s.records[id] = stored
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := [2]string{rec.Scope, rec.Key}
+	if stored, ok := s.records[id]; ok && stored.held(rec) {
+		delete(s.records, id)
+	}
+	return nil
+}
+
+// held reports whether the stored reservation is still the one rec made.
+func (r Record) held(rec *Record) bool {
+	return r.OrderID == "" && r.CreatedAt.Equal(rec.CreatedAt)
+}
@@ -0,0 +1,86 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Reserve(ctx context.Context, scope, key, requestHash string) (*Record, bool, error) {
+	// The unique (scope, key) constraint makes concurrent duplicates race on
+	// the insert; exactly one of them wins. An expired key, or one whose
+	// request crashed before its lease ran out, is taken over.
+	rec := &Record{Scope: scope, Key: key}
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO idempotency_keys (scope, key, request_hash)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (scope, key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, order_id = '', created_at = NOW()
+			WHERE idempotency_keys.created_at < NOW() - make_interval(secs => $4)
+				OR (idempotency_keys.order_id = '' AND idempotency_keys.created_at < NOW() - make_interval(secs => $5))
+		RETURNING request_hash, order_id, created_at`,
+		scope, key, requestHash, TTL.Seconds(), Lease.Seconds(),
+	).Scan(&rec.RequestHash, &rec.OrderID, &rec.CreatedAt)
+	if err == nil {
+		return rec, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("reserve idempotency key: %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+		SELECT request_hash, order_id, created_at
+		FROM idempotency_keys
+		WHERE scope = $1 AND key = $2`,
+		scope, key,
+	).Scan(&rec.RequestHash, &rec.OrderID, &rec.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Released between our insert and select; let the caller retry.
+		return nil, false, ErrInProgress
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("load idempotency key: %w", err)
+	}
+	return rec, false, nil
+}
+
+// Complete and Release match the reservation's created_at, which a
+// takeover resets, so a request whose lease ran out cannot touch the key.
+func (s *PostgresStore) Complete(ctx context.Context, rec *Record, orderID string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE idempotency_keys SET order_id = $4
+		WHERE scope = $1 AND key = $2 AND created_at = $3 AND order_id = ''`,
+		rec.Scope, rec.Key, rec.CreatedAt, orderID,
+	)
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("complete idempotency key: %w", err)
+	}
+	if n == 0 {
+		return ErrTakenOver
+	}
+	return nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, rec *Record) error {
+	_, err := s.db.ExecContext(ctx,
+		`DELETE FROM idempotency_keys WHERE scope = $1 AND key = $2 AND created_at = $3 AND order_id = ''`,
+		rec.Scope, rec.Key, rec.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -2,38 +2,222 @@ package main
 
 import (
 	"context"
+	"expvar"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
-	midtrans2 "github.com/midtrans/midtrans-go"
+	"github.com/vektah/gqlparser/v2/ast"
 	"log"
 	"net/http"
+	"payment-service-iae/auth"
+	"payment-service-iae/catalog"
 	"payment-service-iae/config"
+	"payment-service-iae/customer"
+	"payment-service-iae/database"
 	"payment-service-iae/graph"
+	"payment-service-iae/idempotency"
 	"payment-service-iae/midtrans"
+	"payment-service-iae/notification"
+	"payment-service-iae/outbox"
+	"payment-service-iae/payment"
+	"payment-service-iae/promo"
+	"payment-service-iae/reconcile"
+	"payment-service-iae/savedmethod"
+	"payment-service-iae/subscription"
+	"payment-service-iae/webhook"
+	"time"
+)
+
+const (
+	webhookBatchSize = 20
+	sweepBatchSize   = 100
 )
 
 func main() {
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+	for _, w := range cfg.Warnings {
+		log.Printf("Configuration warning: %s", w)
+	}
 	port := cfg.Port
+	log.Printf("Midtrans environment: %s", cfg.MidtransEnvironment)
+
+	db, err := database.Open(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := database.Migrate(context.Background(), db); err != nil {
+		log.Fatalf("Failed to run database migrations: %v", err)
+	}
 
-	midtransClient := midtrans.NewClient(
+	paymentRepo := payment.NewPostgresRepository(db)
+	paymentService := payment.NewService(paymentRepo)
+	// Subscribers hear about changes made by every instance, not just this
+	// one.
+	paymentService.ShareStatus(paymentRepo)
+	go paymentService.RunStatusFeed(context.Background())
+
+	promos := promo.NewPromos(nil)
+	if cfg.PromoCodesFile != "" {
+		promos, err = promo.LoadPromos(cfg.PromoCodesFile)
+		if err != nil {
+			log.Fatalf("Failed to load promo codes: %v", err)
+		}
+	}
+	promoService := promo.NewService(promo.NewPostgresStore(db), promos)
+
+	// Webhooks always consume the outbox; an external broker is optional.
+	// Promo redemptions of payments that end unpaid are released from it.
+	webhookStore := webhook.NewPostgresStore(db)
+	brokers := outbox.FanOut{webhook.NewEnqueuer(webhookStore), promo.NewReleaser(promoService)}
+	switch cfg.EventBroker {
+	case "nats":
+		natsBroker, err := outbox.NewNATSBroker(context.Background(), cfg.NATSURL, cfg.NATSStream, cfg.NATSSubjectPrefix)
+		if err != nil {
+			log.Fatalf("Failed to connect event broker: %v", err)
+		}
+		defer natsBroker.Close()
+		brokers = append(brokers, natsBroker)
+	case "log":
+		brokers = append(brokers, outbox.LogBroker{})
+	}
+	relay := outbox.NewRelay(outbox.NewPostgresStore(db), brokers, cfg.OutboxPollInterval, cfg.OutboxBatchSize)
+	go relay.Run(context.Background())
+	if cfg.EventBroker != "" {
+		log.Printf("Publishing payment events via %s", cfg.EventBroker)
+	}
+
+	dispatcher := webhook.NewDispatcher(webhookStore, cfg.WebhookTimeout, cfg.WebhookPollInterval, webhookBatchSize, cfg.WebhookMaxAttempts)
+	go dispatcher.Run(context.Background())
+
+	midtransClient, err := midtrans.NewClient(
 		cfg.MidtransServerKey,
-		midtrans2.Sandbox,
+		cfg.MidtransEnvironment.Midtrans(),
+		cfg.MidtransBaseURL,
 	)
+	if err != nil {
+		log.Fatalf("Failed to configure Midtrans client: %v", err)
+	}
+	if cfg.MidtransBaseURL != "" {
+		log.Printf("Midtrans requests go to %s", cfg.MidtransBaseURL)
+	}
+
+	validator, err := auth.NewValidator(auth.Config{
+		HMACSecret: cfg.JWTSecret,
+		JWKSURL:    cfg.JWKSURL,
+		Issuer:     cfg.JWTIssuer,
+		Audience:   cfg.JWTAudience,
+		Leeway:     30 * time.Second,
+	})
+	if err != nil {
+		log.Fatalf("Failed to configure authentication: %v", err)
+	}
+
+	var userService *customer.UserServiceClient
+	if cfg.UserServiceURL != "" {
+		userService = customer.NewUserServiceClient(cfg.UserServiceURL)
+	}
+	customerLookup := customer.NewLookup(userService, cfg.CustomerFallback)
+
+	var books catalog.Catalog
+	if cfg.BookCatalogURL != "" {
+		books = catalog.NewHTTPCatalog(cfg.BookCatalogURL)
+	} else {
+		books, err = catalog.LoadStaticCatalog(cfg.BookPricesFile)
+		if err != nil {
+			log.Fatalf("Failed to load book prices: %v", err)
+		}
+	}
 
-	authMiddleware := func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			ctx := context.WithValue(r.Context(), "user", "user-123")
-			next.ServeHTTP(w, r.WithContext(ctx))
+	optionsPolicy := payment.DefaultOptionsPolicy()
+	if cfg.OptionsPolicyFile != "" {
+		optionsPolicy, err = payment.LoadOptionsPolicy(cfg.OptionsPolicyFile)
+		if err != nil {
+			log.Fatalf("Failed to load payment options policy: %v", err)
+		}
+	}
+	var returnURL string
+	if cfg.PublicBaseURL != "" {
+		returnURL = cfg.PublicBaseURL + notification.ReturnPath
+	}
 
-		})
+	plans := subscription.NewPlans(nil)
+	if cfg.SubscriptionPlans != "" {
+		plans, err = subscription.LoadPlans(cfg.SubscriptionPlans)
+		if err != nil {
+			log.Fatalf("Failed to load subscription plans: %v", err)
+		}
 	}
+	subscriptionService := subscription.NewService(subscription.NewPostgresStore(db), plans, midtransClient, paymentService)
+	savedMethods := savedmethod.NewPostgresStore(db)
+
+	// Notifications, the reconciler and the sweeper all apply updates
+	// through paymentService, so paid payments get the same follow-up
+	// whichever of them saw it first.
+	paymentService.OnPaid(
+		func(ctx context.Context, p *payment.Payment, _ payment.StatusUpdate) error {
+			return subscriptionService.RecordCharge(ctx, p)
+		},
+		savedmethod.SaveCards(savedMethods),
+	)
+
+	reconciler := reconcile.NewReconciler(
+		paymentService,
+		midtransClient,
+		cfg.ReconcileAfter,
+		cfg.ReconcileInterval,
+		cfg.ReconcileJitter,
+		cfg.ReconcileBatchSize,
+	)
+	go reconciler.Run(context.Background())
+	expvar.Publish("reconciler", expvar.Func(func() any { return reconciler.Stats() }))
+
+	sweeper := reconcile.NewSweeper(paymentService, midtransClient, cfg.ExpiryGrace, cfg.ExpirySweepInterval, sweepBatchSize)
+	go sweeper.Run(context.Background())
+	expvar.Publish("expiry_sweeper", expvar.Func(func() any { return sweeper.Stats() }))
+
+	resolver := graph.NewResolver(
+		midtransClient,
+		paymentService,
+		customerLookup,
+		idempotency.NewPostgresStore(db),
+		books,
+		webhookStore,
+		cfg.PaymentExpiry,
+		optionsPolicy,
+		returnURL,
+		subscriptionService,
+		savedMethods,
+		promoService,
+		cfg.Pricing,
+	)
 
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: graph.NewResolver(midtransClient)}))
+	srv := handler.New(graph.NewExecutableSchema(graph.Config{Resolvers: resolver}))
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc:              auth.WebsocketInit(validator),
+	})
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
-	http.Handle("/query", authMiddleware(srv))
+	http.Handle("/query", auth.Middleware(validator)(srv))
+	http.Handle("/notifications/midtrans", notification.NewHandler(cfg.MidtransServerKey, midtransClient, paymentService, subscriptionService))
+	http.Handle(notification.ReturnPath, notification.NewReturnHandler(paymentService))
 
 	log.Printf("connect to http://localhost:%s/ for GraphQL playground", port)
 	log.Fatal(http.ListenAndServe(":"+port, nil))
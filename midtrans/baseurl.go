@@ -0,0 +1,47 @@
+package midtrans
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/midtrans/midtrans-go"
+)
+
+// The SDK derives its endpoints from the environment (api.* for Core API,
+// app.* for Snap) with no way to change the host, so a base-URL override is
+// applied by rewriting outgoing requests at the transport level.
+type baseURLTransport struct {
+	base *url.URL
+	next http.RoundTripper
+}
+
+func (t *baseURLTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.base.Scheme
+	req.URL.Host = t.base.Host
+	req.URL.Path = strings.TrimSuffix(t.base.Path, "/") + req.URL.Path
+	req.Host = t.base.Host
+	return t.next.RoundTrip(req)
+}
+
+// newHTTPClient returns the SDK HTTP client, sending every request to baseURL
+// instead of the Midtrans hosts when one is given.
+func newHTTPClient(env midtrans.EnvironmentType, baseURL string) (*midtrans.HttpClientImplementation, error) {
+	client := midtrans.GetHttpClient(env)
+	if baseURL == "" {
+		return client, nil
+	}
+
+	base, err := url.Parse(baseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid Midtrans base URL %q", baseURL)
+	}
+
+	client.HttpClient = &http.Client{
+		Timeout:   midtrans.DefaultHttpTimeout,
+		Transport: &baseURLTransport{base: base, next: http.DefaultTransport},
+	}
+	return client, nil
+}
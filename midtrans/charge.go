@@ -0,0 +1,153 @@
+package midtrans
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
+
+	"payment-service-iae/gateway"
+)
+
+// Core API action names carrying the customer-facing links.
+const (
+	actionQRCode   = "generate-qr-code"
+	actionDeeplink = "deeplink-redirect"
+)
+
+// vaBanks maps our virtual account methods to the bank_transfer bank.
+var vaBanks = map[string]midtrans.Bank{
+	"bca_va":     midtrans.BankBca,
+	"bni_va":     midtrans.BankBni,
+	"bri_va":     midtrans.BankBri,
+	"permata_va": midtrans.BankPermata,
+}
+
+// DirectCharge charges the order through the Core API /v2/charge endpoint,
+// for clients that cannot send the customer to the Snap page.
+func (c *Client) DirectCharge(ctx context.Context, charge gateway.DirectChargeRequest) (*gateway.DirectChargeResult, error) {
+	if err := checkItems(charge.Items, charge.Total, charge.Amount); err != nil {
+		return nil, err
+	}
+
+	req, err := toChargeReq(charge, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp, midtransErr := c.coreClient.ChargeTransaction(req)
+	if err := wrapError(midtransErr); err != nil {
+		log.Printf("Midtrans API error: %v", err)
+		return nil, err
+	}
+
+	status, ok := ParseStatus(resp.TransactionStatus)
+	if !ok {
+		return nil, fmt.Errorf("unknown Midtrans transaction_status %q for %s", resp.TransactionStatus, charge.OrderID)
+	}
+	result := &gateway.DirectChargeResult{
+		TransactionID: resp.TransactionID,
+		Status:        status,
+		FraudStatus:   resp.FraudStatus,
+		PaymentType:   resp.PaymentType,
+		Instructions:  toInstructions(charge.Method, resp),
+		RedirectURL:   resp.RedirectURL,
+	}
+	if resp.ExpiryTime != "" {
+		t, err := ParseTime(resp.ExpiryTime)
+		if err != nil {
+			return nil, err
+		}
+		result.ExpiresAt = &t
+	}
+
+	log.Printf("Midtrans %s charge created: %s", charge.Method, resp.TransactionID)
+	return result, nil
+}
+
+func toChargeReq(charge gateway.DirectChargeRequest, now time.Time) (*coreapi.ChargeReq, error) {
+	amount, err := rupiah(charge.Amount)
+	if err != nil {
+		return nil, err
+	}
+	req := &coreapi.ChargeReq{
+		TransactionDetails: midtrans.TransactionDetails{
+			OrderID:  charge.OrderID,
+			GrossAmt: amount,
+		},
+		CustomerDetails: toCustomerDetails(charge.Customer),
+	}
+	if len(charge.Items) > 0 {
+		items, err := toItemDetails(charge.Items)
+		if err != nil {
+			return nil, err
+		}
+		req.Items = &items
+	}
+	if minutes := int(charge.Expiry / time.Minute); minutes > 0 {
+		req.CustomExpiry = &coreapi.CustomExpiry{
+			OrderTime:      now.Format(expiryTimeLayout),
+			ExpiryDuration: minutes,
+			Unit:           "minute",
+		}
+	}
+	fields := []**string{&req.CustomField1, &req.CustomField2, &req.CustomField3}
+	for i, f := range charge.CustomFields {
+		if i < len(fields) && f != "" {
+			*fields[i] = &f
+		}
+	}
+
+	switch method := charge.Method; {
+	case vaBanks[method] != "":
+		req.PaymentType = coreapi.PaymentTypeBankTransfer
+		req.BankTransfer = &coreapi.BankTransferDetails{Bank: vaBanks[method]}
+	case method == "qris":
+		req.PaymentType = coreapi.PaymentTypeQris
+		req.Qris = &coreapi.QrisDetails{Acquirer: "gopay"}
+	case method == "gopay":
+		req.PaymentType = coreapi.PaymentTypeGopay
+		req.Gopay = &coreapi.GopayDetails{
+			EnableCallback: charge.CallbackURL != "",
+			CallbackUrl:    charge.CallbackURL,
+		}
+	case method == "shopeepay":
+		req.PaymentType = coreapi.PaymentTypeShopeepay
+		req.ShopeePay = &coreapi.ShopeePayDetails{CallbackUrl: charge.CallbackURL}
+	case method == "credit_card" && charge.CardToken != "":
+		// One-click: the saved token is charged without 3-D Secure; the
+		// response carries a redirect_url if the issuer insists on it.
+		req.PaymentType = coreapi.PaymentTypeCreditCard
+		req.CreditCard = &coreapi.CreditCardDetails{TokenID: charge.CardToken}
+	default:
+		return nil, fmt.Errorf("payment method %q cannot be charged directly", method)
+	}
+	return req, nil
+}
+
+// toInstructions picks what the customer needs out of the charge response.
+func toInstructions(method string, resp *coreapi.ChargeResponse) gateway.Instructions {
+	in := gateway.Instructions{Method: method}
+	if bank, ok := vaBanks[method]; ok {
+		in.Bank = string(bank)
+		in.VANumber = resp.PermataVaNumber
+		for _, va := range resp.VaNumbers {
+			if va.Bank == in.Bank {
+				in.VANumber = va.VANumber
+			}
+		}
+	}
+	in.QRString = resp.QRString
+	for _, a := range resp.Actions {
+		switch a.Name {
+		case actionQRCode:
+			in.QRImageURL = a.URL
+		case actionDeeplink:
+			in.DeeplinkURL = a.URL
+		}
+	}
+	return in
+}
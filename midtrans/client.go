@@ -1,39 +1,195 @@
 package midtrans
 
 import (
+	"context"
 	"github.com/midtrans/midtrans-go"
+	"github.com/midtrans/midtrans-go/coreapi"
 	"github.com/midtrans/midtrans-go/snap"
 	"log"
+	"payment-service-iae/gateway"
+	"time"
 )
 
+// Midtrans rejects item names longer than this.
+const maxItemNameLength = 50
+
+// expiryTimeLayout is the start_time format Snap expects.
+const expiryTimeLayout = "2006-01-02 15:04:05 -0700"
+
+// Client is the Midtrans adapter behind gateway.Gateway: Snap for hosted
+// checkout, Core API for direct charges, status, cancel and refund.
 type Client struct {
 	snapClient snap.Client
+	coreClient coreapi.Client
 }
 
-func NewClient(serverKey string, env midtrans.EnvironmentType) *Client {
+var _ gateway.Gateway = (*Client)(nil)
+
+// NewClient talks to the Midtrans endpoints for env. A non-empty baseURL
+// sends both Snap and Core API requests to that host instead, e.g. the
+// local emulator in midtrans/emulator.
+func NewClient(serverKey string, env midtrans.EnvironmentType, baseURL string) (*Client, error) {
+	httpClient, err := newHTTPClient(env, baseURL)
+	if err != nil {
+		return nil, err
+	}
+
 	c := new(snap.Client)
 	c.New(serverKey, env)
-	return &Client{snapClient: *c}
+	c.HttpClient = httpClient
+
+	core := new(coreapi.Client)
+	core.New(serverKey, env)
+	core.HttpClient = httpClient
+
+	return &Client{snapClient: *c, coreClient: *core}, nil
 }
 
-func (c *Client) CreateTransaction(orderID string, amount int64, customer *midtrans.CustomerDetails) (*snap.Response, error) {
+// Charge opens a Snap transaction. When items are given, Midtrans requires
+// their total to equal the gross amount, so a mismatch is rejected up front.
+func (c *Client) Charge(ctx context.Context, charge gateway.ChargeRequest) (*gateway.ChargeResult, error) {
+	if err := checkItems(charge.Items, charge.Total, charge.Amount); err != nil {
+		return nil, err
+	}
+	amount, err := rupiah(charge.Amount)
+	if err != nil {
+		return nil, err
+	}
+
 	req := &snap.Request{
 		TransactionDetails: midtrans.TransactionDetails{
-			OrderID:  orderID,
+			OrderID:  charge.OrderID,
 			GrossAmt: amount,
 		},
-		CreditCard: &snap.CreditCardDetails{
-			Secure: true,
-		},
-		CustomerDetail: customer,
+		CreditCard:      toCreditCardDetails(charge.CreditCard),
+		CustomerDetail:  toCustomerDetails(charge.Customer),
+		Expiry:          toExpiryDetails(time.Now(), charge.Expiry),
+		EnabledPayments: toEnabledPayments(charge.PaymentMethods),
+	}
+	if len(charge.Items) > 0 {
+		items, err := toItemDetails(charge.Items)
+		if err != nil {
+			return nil, err
+		}
+		req.Items = &items
+	}
+	if charge.FinishURL != "" {
+		req.Callbacks = &snap.Callbacks{Finish: charge.FinishURL}
+	}
+	if charge.CreditCard != nil && charge.CreditCard.SaveCard {
+		// Snap keeps saved cards per user_id and offers them next time.
+		req.UserId = charge.CustomerID
+	}
+	fields := []*string{&req.CustomField1, &req.CustomField2, &req.CustomField3}
+	for i, f := range charge.CustomFields {
+		if i < len(fields) {
+			*fields[i] = f
+		}
 	}
 
-	resp, err := c.snapClient.CreateTransaction(req)
-	if err != nil {
+	resp, midtransErr := c.snapClient.CreateTransaction(req)
+	if err := wrapError(midtransErr); err != nil {
 		log.Printf("Midtrans API error: %v", err)
-	} else {
-		log.Printf("Midtrans transaction created successfully: %s", resp.Token)
+		return nil, err
+	}
+
+	log.Printf("Midtrans transaction created successfully: %s", resp.Token)
+	return &gateway.ChargeResult{Token: resp.Token, RedirectURL: resp.RedirectURL}, nil
+}
+
+func toItemDetails(items []gateway.Item) ([]midtrans.ItemDetails, error) {
+	details := make([]midtrans.ItemDetails, 0, len(items))
+	for _, item := range items {
+		price, err := rupiah(item.Price)
+		if err != nil {
+			return nil, err
+		}
+		details = append(details, midtrans.ItemDetails{
+			ID:    item.ID,
+			Name:  truncate(item.Name, maxItemNameLength),
+			Price: price,
+			Qty:   item.Quantity,
+		})
+	}
+	return details, nil
+}
+
+// toExpiryDetails expresses expiry in whole minutes from start, rounding
+// down so Midtrans never accepts a payment after the expiry we store.
+func toExpiryDetails(start time.Time, expiry time.Duration) *snap.ExpiryDetails {
+	minutes := int64(expiry / time.Minute)
+	if minutes <= 0 {
+		return nil
+	}
+	return &snap.ExpiryDetails{
+		StartTime: start.Format(expiryTimeLayout),
+		Unit:      "minute",
+		Duration:  minutes,
 	}
+}
 
-	return resp, err
+// snapPaymentTypes holds the enabled_payments names that differ from ours.
+var snapPaymentTypes = map[string]snap.SnapPaymentType{
+	"qris": "other_qris",
+}
+
+func toEnabledPayments(methods []string) []snap.SnapPaymentType {
+	if len(methods) == 0 {
+		return nil
+	}
+	types := make([]snap.SnapPaymentType, 0, len(methods))
+	for _, m := range methods {
+		t, ok := snapPaymentTypes[m]
+		if !ok {
+			t = snap.SnapPaymentType(m)
+		}
+		types = append(types, t)
+	}
+	return types
+}
+
+// toCreditCardDetails always asks for 3-D Secure and adds the card options
+// the caller chose.
+func toCreditCardDetails(o *gateway.CardOptions) *snap.CreditCardDetails {
+	details := &snap.CreditCardDetails{Secure: true}
+	if o == nil {
+		return details
+	}
+	details.SaveCard = o.SaveCard
+	if in := o.Installment; in != nil {
+		terms := &snap.InstallmentTermsDetail{}
+		banks := map[string]*[]int8{
+			"bni": &terms.Bni, "mandiri": &terms.Mandiri, "cimb": &terms.Cimb, "mega": &terms.Mega,
+			"bca": &terms.Bca, "bri": &terms.Bri, "maybank": &terms.Maybank, "offline": &terms.Offline,
+		}
+		for bank, months := range in.Terms {
+			if field, ok := banks[bank]; ok {
+				for _, m := range months {
+					*field = append(*field, int8(m))
+				}
+			}
+		}
+		details.Installment = &snap.InstallmentDetail{Required: in.Required, Terms: terms}
+	}
+	return details
+}
+
+func toCustomerDetails(c *gateway.Customer) *midtrans.CustomerDetails {
+	if c == nil {
+		return nil
+	}
+	return &midtrans.CustomerDetails{
+		FName: c.FirstName,
+		LName: c.LastName,
+		Email: c.Email,
+		Phone: c.Phone,
+	}
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max])
 }
@@ -0,0 +1,193 @@
+package emulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type chargeRequest struct {
+	PaymentType        string             `json:"payment_type"`
+	TransactionDetails transactionDetails `json:"transaction_details"`
+	ItemDetails        []itemDetail       `json:"item_details"`
+	BankTransfer       *struct {
+		Bank string `json:"bank"`
+	} `json:"bank_transfer"`
+	Gopay *struct {
+		CallbackURL string `json:"callback_url"`
+	} `json:"gopay"`
+	ShopeePay *struct {
+		CallbackURL string `json:"callback_url"`
+	} `json:"shopeepay"`
+	CreditCard *struct {
+		TokenID string `json:"token_id"`
+	} `json:"credit_card"`
+	CustomExpiry *struct {
+		OrderTime      string `json:"order_time"`
+		ExpiryDuration int64  `json:"expiry_duration"`
+		Unit           string `json:"unit"`
+	} `json:"custom_expiry"`
+}
+
+type chargeResponse struct {
+	statusResponse
+	VANumbers       []vaNumber `json:"va_numbers,omitempty"`
+	PermataVANumber string     `json:"permata_va_number,omitempty"`
+	QRString        string     `json:"qr_string,omitempty"`
+	Acquirer        string     `json:"acquirer,omitempty"`
+	Actions         []action   `json:"actions,omitempty"`
+	ExpiryTime      string     `json:"expiry_time"`
+}
+
+type vaNumber struct {
+	Bank     string `json:"bank"`
+	VANumber string `json:"va_number"`
+}
+
+type action struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+var vaBanks = map[string]bool{"bca": true, "bni": true, "bri": true, "permata": true}
+
+var customExpiryUnits = map[string]time.Duration{
+	"second": time.Second, "minute": time.Minute, "hour": time.Hour, "day": 24 * time.Hour,
+}
+
+// createCharge handles the Core API charge for the methods the service
+// charges directly. QR code and deeplink URLs open the emulator's payment
+// page, which stands in for the customer's banking or wallet app.
+func (s *Server) createCharge(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		writeCoreError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	var req chargeRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeCoreError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+
+	problems := checkDetails(req.TransactionDetails, req.ItemDetails)
+	callbackURL := ""
+	expiry := 15 * time.Minute
+	var card *savedCard
+	switch req.PaymentType {
+	case "credit_card":
+		// Only saved tokens can be charged; the emulator has no card
+		// tokenization of its own.
+		if req.CreditCard != nil {
+			s.mu.Lock()
+			card = s.savedCards[req.CreditCard.TokenID]
+			s.mu.Unlock()
+		}
+		if card == nil || !card.ExpiresAt.After(s.now()) {
+			problems = append(problems, "credit_card.token_id is missing, invalid, or expired")
+		}
+	case "bank_transfer":
+		if req.BankTransfer == nil || !vaBanks[req.BankTransfer.Bank] {
+			problems = append(problems, "bank_transfer.bank must be one of bca, bni, bri or permata")
+		}
+		expiry = 24 * time.Hour
+	case "qris":
+	case "gopay":
+		if req.Gopay != nil {
+			callbackURL = req.Gopay.CallbackURL
+		}
+	case "shopeepay":
+		if req.ShopeePay != nil {
+			callbackURL = req.ShopeePay.CallbackURL
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("payment_type %q is not supported by the emulator", req.PaymentType))
+	}
+	if e := req.CustomExpiry; e != nil {
+		unit := e.Unit
+		if unit == "" {
+			unit = "minute"
+		}
+		if _, ok := customExpiryUnits[unit]; !ok {
+			problems = append(problems, "custom_expiry.unit must be second, minute, hour or day")
+		} else if e.ExpiryDuration < 1 {
+			problems = append(problems, "custom_expiry.expiry_duration must be at least 1")
+		} else {
+			expiry = time.Duration(e.ExpiryDuration) * customExpiryUnits[unit]
+		}
+		if e.OrderTime != "" {
+			if _, err := time.Parse(expiryTimeLayout, e.OrderTime); err != nil {
+				problems = append(problems, "custom_expiry.order_time must be in yyyy-MM-dd HH:mm:ss Z format")
+			}
+		}
+	}
+	if len(problems) > 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"status_code":         "400",
+			"status_message":      "One or more parameters in the payload is invalid.",
+			"validation_messages": problems,
+		})
+		return
+	}
+
+	s.mu.Lock()
+	if _, exists := s.transactions[req.TransactionDetails.OrderID]; exists {
+		s.mu.Unlock()
+		writeCoreError(w, http.StatusNotAcceptable, "The request could not be completed due to a conflict with the current state of the target resource, please try again")
+		return
+	}
+	now := s.now()
+	t := &transaction{
+		OrderID:       req.TransactionDetails.OrderID,
+		Token:         uuid.NewString(),
+		TransactionID: uuid.NewString(),
+		GrossAmount:   req.TransactionDetails.GrossAmount,
+		FinishURL:     callbackURL,
+	}
+	if card != nil {
+		// One-click card payments capture straight away.
+		t.Card = card
+		t.setStatus("capture", req.PaymentType, "accept", now)
+	} else {
+		t.setStatus("pending", req.PaymentType, "accept", now)
+	}
+	s.transactions[t.OrderID] = t
+	s.byToken[t.Token] = t.OrderID
+	resp := chargeResponse{
+		statusResponse: newStatusResponse(t, "Success, transaction is created"),
+		ExpiryTime:     formatTime(now.Add(expiry)),
+	}
+	n := s.notification(t)
+	s.mu.Unlock()
+
+	page := fmt.Sprintf("%s/snap/v3/redirection/%s", publicURL(s.cfg, r), t.Token)
+	switch req.PaymentType {
+	case "bank_transfer":
+		number := fmt.Sprintf("%011d", rand.Int64N(1e11))
+		if req.BankTransfer.Bank == "permata" {
+			resp.PermataVANumber = number
+		} else {
+			resp.VANumbers = []vaNumber{{Bank: req.BankTransfer.Bank, VANumber: number}}
+		}
+	case "qris":
+		resp.QRString = fmt.Sprintf("00020101021126EMULATOR%s5303360540%d", t.TransactionID, t.GrossAmount)
+		resp.Acquirer = "gopay"
+		resp.Actions = []action{{Name: "generate-qr-code", Method: http.MethodGet, URL: page}}
+	case "gopay":
+		resp.Actions = []action{
+			{Name: "generate-qr-code", Method: http.MethodGet, URL: page},
+			{Name: "deeplink-redirect", Method: http.MethodGet, URL: page},
+		}
+	case "shopeepay":
+		resp.Actions = []action{{Name: "deeplink-redirect", Method: http.MethodGet, URL: page}}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+	go s.deliver(n)
+}
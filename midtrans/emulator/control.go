@@ -0,0 +1,207 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownTransaction is returned for an order ID the emulator has not
+// issued a Snap token for.
+var ErrUnknownTransaction = errors.New("emulator: unknown transaction")
+
+// Simulate moves orderID to status (settlement, deny, expire, ...) as if the
+// customer had paid with paymentType, and delivers the signed notification
+// before returning. A delivery failure is returned after the state change.
+func (s *Server) Simulate(orderID, status, paymentType string) error {
+	_, err := s.simulate(orderID, status, paymentType, "")
+	return err
+}
+
+// Notify resends the notification for orderID's current state.
+func (s *Server) Notify(orderID string) error {
+	s.mu.Lock()
+	t, ok := s.transactions[orderID]
+	if !ok || !t.started() {
+		s.mu.Unlock()
+		return ErrUnknownTransaction
+	}
+	n := s.notification(t)
+	s.mu.Unlock()
+
+	_, err := s.deliver(n)
+	return err
+}
+
+func (s *Server) simulate(orderID, status, paymentType, fraudStatus string) (int, error) {
+	if !validStatus(status) {
+		return 0, fmt.Errorf("emulator: unknown transaction_status %q", status)
+	}
+	if paymentType == "" {
+		paymentType = "bank_transfer"
+	}
+	if fraudStatus == "" && (status == "capture" || status == "settlement") {
+		fraudStatus = "accept"
+	}
+
+	s.mu.Lock()
+	t, ok := s.transactions[orderID]
+	if !ok {
+		s.mu.Unlock()
+		return 0, ErrUnknownTransaction
+	}
+	t.setStatus(status, paymentType, fraudStatus, s.now())
+	if t.SaveCard && t.Card == nil && t.PaymentType == "credit_card" && (status == "capture" || status == "settlement") {
+		t.Card = s.saveCard()
+	}
+	n := s.notification(t)
+	s.mu.Unlock()
+
+	return s.deliver(n)
+}
+
+// saveCard issues a saved token for a test card, valid for three years.
+// Callers must hold s.mu.
+func (s *Server) saveCard() *savedCard {
+	c := &savedCard{
+		Token:      "481111-1114-" + uuid.NewString(),
+		ExpiresAt:  s.now().AddDate(3, 0, 0),
+		MaskedCard: "481111-1114",
+		Bank:       "bni",
+		CardType:   "credit",
+	}
+	s.savedCards[c.Token] = c
+	return c
+}
+
+// simulateStatus is the HTTP form of Simulate. It accepts JSON or a form
+// post from the payment page, which is sent back to the page afterwards.
+func (s *Server) simulateStatus(w http.ResponseWriter, r *http.Request) {
+	orderID := r.PathValue("orderID")
+
+	var req struct {
+		TransactionStatus string `json:"transaction_status"`
+		PaymentType       string `json:"payment_type"`
+		FraudStatus       string `json:"fraud_status"`
+	}
+	form := strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+	if form {
+		req.TransactionStatus = r.PostFormValue("transaction_status")
+		req.PaymentType = r.PostFormValue("payment_type")
+		req.FraudStatus = r.PostFormValue("fraud_status")
+	} else if err := decodeJSON(r, &req); err != nil {
+		writeCoreError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+
+	if !validStatus(req.TransactionStatus) {
+		writeCoreError(w, http.StatusBadRequest, fmt.Sprintf("unknown transaction_status %q", req.TransactionStatus))
+		return
+	}
+
+	code, err := s.simulate(orderID, req.TransactionStatus, req.PaymentType, req.FraudStatus)
+	if errors.Is(err, ErrUnknownTransaction) {
+		writeCoreError(w, http.StatusNotFound, "Transaction doesn't exist.")
+		return
+	}
+
+	if form {
+		s.mu.Lock()
+		token := s.transactions[orderID].Token
+		s.mu.Unlock()
+		http.Redirect(w, r, "/snap/v3/redirection/"+token, http.StatusSeeOther)
+		return
+	}
+	result := map[string]any{"notification_status": code}
+	if err != nil {
+		result["notification_error"] = err.Error()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) resendNotification(w http.ResponseWriter, r *http.Request) {
+	err := s.Notify(r.PathValue("orderID"))
+	if errors.Is(err, ErrUnknownTransaction) {
+		writeCoreError(w, http.StatusNotFound, "Transaction doesn't exist.")
+		return
+	}
+	if err != nil {
+		writeCoreError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var paymentPageTemplate = template.Must(template.New("payment").Parse(`<!DOCTYPE html>
+<html>
+<head><title>Midtrans emulator – {{.OrderID}}</title></head>
+<body>
+<h1>Order {{.OrderID}}</h1>
+<p>Amount: IDR {{.GrossAmount}}</p>
+<p>Status: {{if .Status}}{{.Status}}{{else}}not started{{end}}</p>
+{{range .Actions}}
+<form method="post" action="/emulator/transactions/{{$.OrderID}}/status">
+<input type="hidden" name="transaction_status" value="{{.}}">
+<button type="submit">{{.}}</button>
+</form>
+{{end}}
+{{with .FinishURL}}<p><a href="{{.}}">Back to merchant</a></p>{{end}}
+</body>
+</html>
+`))
+
+// finishRedirect is the finish URL with the parameters Snap appends when it
+// sends the customer back.
+func finishRedirect(t *transaction) string {
+	if t.FinishURL == "" || !t.started() {
+		return ""
+	}
+	u, err := url.Parse(t.FinishURL)
+	if err != nil {
+		return ""
+	}
+	q := u.Query()
+	q.Set("order_id", t.OrderID)
+	q.Set("status_code", statusCodes[t.Status])
+	q.Set("transaction_status", t.Status)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// paymentPage stands in for the Snap payment page the redirect URL points
+// at, with a button per outcome.
+func (s *Server) paymentPage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	orderID, ok := s.byToken[r.PathValue("token")]
+	var data struct {
+		OrderID     string
+		GrossAmount int64
+		Status      string
+		Actions     []string
+		FinishURL   string
+	}
+	if ok {
+		t := s.transactions[orderID]
+		data.OrderID = t.OrderID
+		data.GrossAmount = t.GrossAmount
+		data.Status = t.Status
+		data.FinishURL = finishRedirect(t)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	data.Actions = []string{"pending", "settlement", "deny", "expire", "failure"}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := paymentPageTemplate.Execute(w, data); err != nil {
+		log.Printf("emulator: failed to render payment page: %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package emulator
+
+import (
+	"errors"
+	"net/http"
+)
+
+type statusResponse struct {
+	StatusCode        string          `json:"status_code"`
+	StatusMessage     string          `json:"status_message"`
+	TransactionID     string          `json:"transaction_id"`
+	OrderID           string          `json:"order_id"`
+	GrossAmount       string          `json:"gross_amount"`
+	Currency          string          `json:"currency"`
+	PaymentType       string          `json:"payment_type,omitempty"`
+	TransactionTime   string          `json:"transaction_time,omitempty"`
+	TransactionStatus string          `json:"transaction_status"`
+	FraudStatus       string          `json:"fraud_status,omitempty"`
+	SettlementTime    string          `json:"settlement_time,omitempty"`
+	MaskedCard        string          `json:"masked_card,omitempty"`
+	Bank              string          `json:"bank,omitempty"`
+	CardType          string          `json:"card_type,omitempty"`
+	SavedTokenID      string          `json:"saved_token_id,omitempty"`
+	SavedTokenExpiry  string          `json:"saved_token_id_expired_at,omitempty"`
+	Refunds           []refundDetails `json:"refunds,omitempty"`
+}
+
+type refundDetails struct {
+	RefundChargebackID int    `json:"refund_chargeback_id"`
+	RefundAmount       string `json:"refund_amount"`
+	Reason             string `json:"reason"`
+	RefundKey          string `json:"refund_key"`
+	CreatedAt          string `json:"created_at"`
+}
+
+type refundResponse struct {
+	statusResponse
+	RefundChargebackID int    `json:"refund_chargeback_id"`
+	RefundAmount       string `json:"refund_amount"`
+	RefundKey          string `json:"refund_key"`
+}
+
+func newStatusResponse(t *transaction, message string) statusResponse {
+	resp := statusResponse{
+		StatusCode:        statusCodes[t.Status],
+		StatusMessage:     message,
+		TransactionID:     t.TransactionID,
+		OrderID:           t.OrderID,
+		GrossAmount:       formatAmount(t.GrossAmount),
+		Currency:          "IDR",
+		PaymentType:       t.PaymentType,
+		TransactionStatus: t.Status,
+		FraudStatus:       t.FraudStatus,
+	}
+	if !t.TransactionTime.IsZero() {
+		resp.TransactionTime = formatTime(t.TransactionTime)
+	}
+	if c := t.Card; c != nil {
+		resp.MaskedCard = c.MaskedCard
+		resp.Bank = c.Bank
+		resp.CardType = c.CardType
+		if t.SaveCard {
+			resp.SavedTokenID = c.Token
+			resp.SavedTokenExpiry = formatTime(c.ExpiresAt)
+		}
+	}
+	if !t.SettlementTime.IsZero() {
+		resp.SettlementTime = formatTime(t.SettlementTime)
+	}
+	for _, r := range t.Refunds {
+		resp.Refunds = append(resp.Refunds, refundDetails{
+			RefundChargebackID: r.Chargeback,
+			RefundAmount:       formatAmount(r.Amount),
+			Reason:             r.Reason,
+			RefundKey:          r.Key,
+			CreatedAt:          formatTime(r.CreatedAt),
+		})
+	}
+	return resp
+}
+
+func (s *Server) transactionStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, ok := s.transactions[r.PathValue("orderID")]
+	if !ok || !t.started() {
+		s.mu.Unlock()
+		writeCoreError(w, http.StatusNotFound, "Transaction doesn't exist.")
+		return
+	}
+	resp := newStatusResponse(t, "Success, transaction is found")
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) cancelTransaction(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	t, ok := s.transactions[r.PathValue("orderID")]
+	if !ok {
+		s.mu.Unlock()
+		writeCoreError(w, http.StatusNotFound, "Transaction doesn't exist.")
+		return
+	}
+	if err := t.cancel(); err != nil {
+		s.mu.Unlock()
+		writeTransactionError(w, err)
+		return
+	}
+	resp := newStatusResponse(t, "Success, transaction is canceled")
+	n := s.notification(t)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+	go s.deliver(n)
+}
+
+func (s *Server) refundTransaction(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefundKey string `json:"refund_key"`
+		Amount    int64  `json:"amount"`
+		Reason    string `json:"reason"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeCoreError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+
+	s.mu.Lock()
+	t, ok := s.transactions[r.PathValue("orderID")]
+	if !ok {
+		s.mu.Unlock()
+		writeCoreError(w, http.StatusNotFound, "Transaction doesn't exist.")
+		return
+	}
+	before := len(t.Refunds)
+	refund, err := t.refund(req.RefundKey, req.Amount, req.Reason, s.now())
+	if err != nil {
+		s.mu.Unlock()
+		writeTransactionError(w, err)
+		return
+	}
+	resp := refundResponse{
+		statusResponse:     newStatusResponse(t, "Success, refund request is approved"),
+		RefundChargebackID: refund.Chargeback,
+		RefundAmount:       formatAmount(refund.Amount),
+		RefundKey:          refund.Key,
+	}
+	// A replayed refund key changes nothing, so there is nothing to notify.
+	n := s.notification(t)
+	fresh := len(t.Refunds) > before
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+	if fresh {
+		go s.deliver(n)
+	}
+}
+
+func writeTransactionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNotStarted):
+		writeCoreError(w, http.StatusNotFound, "Transaction doesn't exist.")
+	case errors.Is(err, errRefundTooBig):
+		writeCoreError(w, http.StatusPreconditionFailed, "Refund amount exceeds the remaining amount of the transaction.")
+	default:
+		writeCoreError(w, http.StatusPreconditionFailed, "Merchant cannot modify the status of the transaction.")
+	}
+}
@@ -0,0 +1,145 @@
+// Package emulator is an in-memory stand-in for the Midtrans Snap, Core and
+// Subscription APIs, for local development and integration tests that cannot reach the
+// Midtrans sandbox. Point midtrans.NewClient at it with a base URL.
+package emulator
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config configures an emulator Server.
+type Config struct {
+	// ServerKey is the key clients must authenticate with and the key
+	// notifications are signed with.
+	ServerKey string
+	// NotificationURL receives the HTTP notifications; none are sent when
+	// it is empty.
+	NotificationURL string
+	// PublicURL is the emulator's externally reachable address, used to
+	// build Snap redirect URLs.
+	PublicURL string
+}
+
+// Server emulates the Midtrans endpoints the service uses.
+type Server struct {
+	cfg    Config
+	mux    *http.ServeMux
+	client *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	transactions  map[string]*transaction
+	byToken       map[string]string
+	subscriptions map[string]*subscription
+	savedCards    map[string]*savedCard
+}
+
+func New(cfg Config) *Server {
+	s := &Server{
+		cfg:           cfg,
+		mux:           http.NewServeMux(),
+		client:        &http.Client{Timeout: 10 * time.Second},
+		now:           time.Now,
+		transactions:  make(map[string]*transaction),
+		byToken:       make(map[string]string),
+		subscriptions: make(map[string]*subscription),
+		savedCards:    make(map[string]*savedCard),
+	}
+
+	s.mux.HandleFunc("POST /snap/v1/transactions", s.authenticated(s.createSnapTransaction))
+	s.mux.HandleFunc("POST /v2/charge", s.authenticated(s.createCharge))
+	s.mux.HandleFunc("GET /v2/{orderID}/status", s.authenticated(s.transactionStatus))
+	s.mux.HandleFunc("POST /v2/{orderID}/cancel", s.authenticated(s.cancelTransaction))
+	s.mux.HandleFunc("POST /v2/{orderID}/refund", s.authenticated(s.refundTransaction))
+	s.mux.HandleFunc("POST /v1/subscriptions", s.authenticated(s.createSubscription))
+	s.mux.HandleFunc("GET /v1/subscriptions/{id}", s.authenticated(s.getSubscription))
+	s.mux.HandleFunc("POST /v1/subscriptions/{id}/disable", s.authenticated(s.setSubscriptionStatus("inactive")))
+	s.mux.HandleFunc("POST /v1/subscriptions/{id}/enable", s.authenticated(s.setSubscriptionStatus("active")))
+	s.mux.HandleFunc("POST /v1/subscriptions/{id}/cancel", s.authenticated(s.setSubscriptionStatus("cancelled")))
+
+	s.mux.HandleFunc("GET /snap/v3/redirection/{token}", s.paymentPage)
+	s.mux.HandleFunc("POST /emulator/transactions/{orderID}/status", s.simulateStatus)
+	s.mux.HandleFunc("POST /emulator/transactions/{orderID}/notify", s.resendNotification)
+	s.mux.HandleFunc("POST /emulator/subscriptions/{id}/charge", s.chargeSubscription)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// authenticated checks the HTTP basic auth Midtrans expects: the server key
+// as user name and an empty password.
+func (s *Server) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(user), []byte(s.cfg.ServerKey)) != 1 {
+			writeCoreError(w, http.StatusUnauthorized, "Access denied due to unauthorized transaction, please check client or server key")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// decodeJSON reads an optional JSON body into v; an empty body leaves v
+// untouched.
+func decodeJSON(r *http.Request, v any) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil || len(body) == 0 {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("emulator: failed to write response: %v", err)
+	}
+}
+
+// writeCoreError answers the way the Core API does: the status is repeated
+// as a string in the body.
+func writeCoreError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{
+		"status_code":    strconv.Itoa(status),
+		"status_message": message,
+	})
+}
+
+// writeSnapError answers the way Snap does, with a list of messages.
+func writeSnapError(w http.ResponseWriter, status int, messages ...string) {
+	writeJSON(w, status, map[string][]string{"error_messages": messages})
+}
+
+func formatAmount(amount int64) string {
+	return fmt.Sprintf("%d.00", amount)
+}
+
+func formatTime(t time.Time) string {
+	return t.In(wib).Format("2006-01-02 15:04:05")
+}
+
+// Midtrans reports times in Western Indonesia Time.
+var wib = time.FixedZone("WIB", 7*60*60)
+
+func publicURL(cfg Config, r *http.Request) string {
+	if cfg.PublicURL != "" {
+		return strings.TrimSuffix(cfg.PublicURL, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
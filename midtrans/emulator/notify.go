@@ -0,0 +1,59 @@
+package emulator
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// notification mirrors the HTTP notification Midtrans posts on every
+// status change.
+type notification struct {
+	statusResponse
+	SignatureKey string `json:"signature_key"`
+}
+
+// notification builds the signed payload for t's current state. Callers
+// must hold s.mu.
+func (s *Server) notification(t *transaction) notification {
+	resp := newStatusResponse(t, "midtrans payment notification")
+	return notification{
+		statusResponse: resp,
+		SignatureKey:   sign(resp.OrderID, resp.StatusCode, resp.GrossAmount, s.cfg.ServerKey),
+	}
+}
+
+// sign computes signature_key as documented by Midtrans:
+// SHA512(order_id + status_code + gross_amount + server_key).
+func sign(orderID, statusCode, grossAmount, serverKey string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// deliver posts n to the notification URL once and reports the response
+// status. Unlike Midtrans it does not retry; tests resend explicitly.
+func (s *Server) deliver(n notification) (int, error) {
+	if s.cfg.NotificationURL == "" {
+		return 0, nil
+	}
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := s.client.Post(s.cfg.NotificationURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("emulator: notification for %s failed: %v", n.OrderID, err)
+		return 0, err
+	}
+	resp.Body.Close()
+
+	log.Printf("emulator: notified %s for %s (%s): %d", s.cfg.NotificationURL, n.OrderID, n.TransactionStatus, resp.StatusCode)
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("notification for %s answered %d", n.OrderID, resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
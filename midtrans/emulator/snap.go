@@ -0,0 +1,131 @@
+package emulator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const maxBodyBytes = 1 << 20
+
+type transactionDetails struct {
+	OrderID     string `json:"order_id"`
+	GrossAmount int64  `json:"gross_amount"`
+}
+
+type itemDetail struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Price    int64  `json:"price"`
+	Quantity int64  `json:"quantity"`
+}
+
+type snapRequest struct {
+	TransactionDetails transactionDetails `json:"transaction_details"`
+	ItemDetails        []itemDetail       `json:"item_details"`
+	Callbacks          *struct {
+		Finish string `json:"finish"`
+	} `json:"callbacks"`
+	Expiry *struct {
+		StartTime string `json:"start_time"`
+		Unit      string `json:"unit"`
+		Duration  int64  `json:"duration"`
+	} `json:"expiry"`
+	CreditCard *struct {
+		SaveCard bool `json:"save_card"`
+	} `json:"credit_card"`
+}
+
+const expiryTimeLayout = "2006-01-02 15:04:05 -0700"
+
+var expiryUnits = map[string]bool{
+	"day": true, "days": true, "hour": true, "hours": true, "minute": true, "minutes": true,
+}
+
+// createSnapTransaction applies the same checks Snap does before issuing a
+// token: a unique order ID, a positive amount and items adding up to it.
+func (s *Server) createSnapTransaction(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		writeSnapError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+	var req snapRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeSnapError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+
+	details := req.TransactionDetails
+	problems := checkDetails(details, req.ItemDetails)
+	if e := req.Expiry; e != nil {
+		if !expiryUnits[e.Unit] {
+			problems = append(problems, "expiry.unit must be day, hour or minute")
+		}
+		if e.Duration < 1 {
+			problems = append(problems, "expiry.duration must be at least 1")
+		}
+		if e.StartTime != "" {
+			if _, err := time.Parse(expiryTimeLayout, e.StartTime); err != nil {
+				problems = append(problems, "expiry.start_time must be in yyyy-MM-dd HH:mm:ss Z format")
+			}
+		}
+	}
+	if len(problems) > 0 {
+		writeSnapError(w, http.StatusBadRequest, problems...)
+		return
+	}
+
+	s.mu.Lock()
+	if _, exists := s.transactions[details.OrderID]; exists {
+		s.mu.Unlock()
+		writeSnapError(w, http.StatusBadRequest, "transaction_details.order_id has already been taken")
+		return
+	}
+	t := &transaction{
+		OrderID:       details.OrderID,
+		Token:         uuid.NewString(),
+		TransactionID: uuid.NewString(),
+		GrossAmount:   details.GrossAmount,
+	}
+	if req.Callbacks != nil {
+		t.FinishURL = req.Callbacks.Finish
+	}
+	if req.CreditCard != nil {
+		t.SaveCard = req.CreditCard.SaveCard
+	}
+	s.transactions[t.OrderID] = t
+	s.byToken[t.Token] = t.OrderID
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"token":        t.Token,
+		"redirect_url": fmt.Sprintf("%s/snap/v3/redirection/%s", publicURL(s.cfg, r), t.Token),
+	})
+}
+
+// checkDetails applies the checks Snap and the Core API share: an order ID,
+// a positive amount and items adding up to it.
+func checkDetails(details transactionDetails, items []itemDetail) []string {
+	var problems []string
+	if details.OrderID == "" {
+		problems = append(problems, "transaction_details.order_id is required")
+	}
+	if details.GrossAmount < 1 {
+		problems = append(problems, "transaction_details.gross_amount must be at least 1")
+	}
+	if len(items) > 0 {
+		var total int64
+		for _, item := range items {
+			total += item.Price * item.Quantity
+		}
+		if total != details.GrossAmount {
+			problems = append(problems, "transaction_details.gross_amount is not equal to the sum of item_details")
+		}
+	}
+	return problems
+}
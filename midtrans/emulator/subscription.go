@@ -0,0 +1,335 @@
+package emulator
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnknownSubscription is returned for a subscription ID the emulator has
+// not created.
+var ErrUnknownSubscription = errors.New("emulator: unknown subscription")
+
+var errSubscriptionInactive = errors.New("emulator: subscription is not active")
+
+// subscriptionNamePattern is what Midtrans allows in a subscription name,
+// which becomes part of every charge's order ID.
+var subscriptionNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.~-]{1,40}$`)
+
+type subscription struct {
+	ID              string
+	Name            string
+	Amount          int64
+	PaymentType     string
+	Token           string
+	Interval        int
+	IntervalUnit    string
+	MaxInterval     int
+	CurrentInterval int
+	// Attempts counts charges, paid or not, and numbers their order IDs.
+	// Only paid charges count towards MaxInterval.
+	Attempts   int
+	StartTime  time.Time
+	PreviousAt time.Time
+	NextAt     time.Time
+	// Status is active, inactive (disabled) or cancelled.
+	Status    string
+	CreatedAt time.Time
+}
+
+type subscriptionRequest struct {
+	Name        string `json:"name"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	PaymentType string `json:"payment_type"`
+	Token       string `json:"token"`
+	Schedule    struct {
+		Interval     int    `json:"interval"`
+		IntervalUnit string `json:"interval_unit"`
+		MaxInterval  int    `json:"max_interval"`
+		StartTime    string `json:"start_time"`
+	} `json:"schedule"`
+	Gopay *struct {
+		AccountID string `json:"account_id"`
+	} `json:"gopay"`
+}
+
+type subscriptionResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Amount      string `json:"amount"`
+	Currency    string `json:"currency"`
+	CreatedAt   string `json:"created_at"`
+	Status      string `json:"status"`
+	Token       string `json:"token"`
+	PaymentType string `json:"payment_type"`
+	Schedule    struct {
+		Interval            int    `json:"interval"`
+		IntervalUnit        string `json:"interval_unit"`
+		MaxInterval         int    `json:"max_interval"`
+		CurrentInterval     int    `json:"current_interval"`
+		StartTime           string `json:"start_time"`
+		PreviousExecutionAt string `json:"previous_execution_at,omitempty"`
+		NextExecutionAt     string `json:"next_execution_at,omitempty"`
+	} `json:"schedule"`
+}
+
+func (sub *subscription) next(t time.Time) time.Time {
+	switch sub.IntervalUnit {
+	case "day":
+		return t.AddDate(0, 0, sub.Interval)
+	case "week":
+		return t.AddDate(0, 0, 7*sub.Interval)
+	default:
+		return t.AddDate(0, sub.Interval, 0)
+	}
+}
+
+func (sub *subscription) finished() bool {
+	return sub.MaxInterval > 0 && sub.CurrentInterval >= sub.MaxInterval
+}
+
+func newSubscriptionResponse(sub *subscription) subscriptionResponse {
+	resp := subscriptionResponse{
+		ID:          sub.ID,
+		Name:        sub.Name,
+		Amount:      fmt.Sprintf("%d", sub.Amount),
+		Currency:    "IDR",
+		CreatedAt:   sub.CreatedAt.In(wib).Format(expiryTimeLayout),
+		Status:      sub.Status,
+		Token:       sub.Token,
+		PaymentType: sub.PaymentType,
+	}
+	resp.Schedule.Interval = sub.Interval
+	resp.Schedule.IntervalUnit = sub.IntervalUnit
+	resp.Schedule.MaxInterval = sub.MaxInterval
+	resp.Schedule.CurrentInterval = sub.CurrentInterval
+	resp.Schedule.StartTime = sub.StartTime.In(wib).Format(expiryTimeLayout)
+	if !sub.PreviousAt.IsZero() {
+		resp.Schedule.PreviousExecutionAt = sub.PreviousAt.In(wib).Format(expiryTimeLayout)
+	}
+	if sub.Status == "active" && !sub.finished() {
+		resp.Schedule.NextExecutionAt = sub.NextAt.In(wib).Format(expiryTimeLayout)
+	}
+	return resp
+}
+
+// writeSubscriptionError answers the way the Subscription API does, with a
+// validation_message list.
+func writeSubscriptionError(w http.ResponseWriter, status int, messages ...string) {
+	writeJSON(w, status, map[string]any{
+		"status_message":     "Bad request",
+		"validation_message": messages,
+	})
+}
+
+// createSubscription checks a subscription the way Midtrans does. Nothing
+// is charged until ChargeSubscription is called; the emulator has no clock
+// of its own.
+func (s *Server) createSubscription(w http.ResponseWriter, r *http.Request) {
+	var req subscriptionRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeSubscriptionError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+
+	var problems []string
+	if !subscriptionNamePattern.MatchString(req.Name) {
+		problems = append(problems, "name must be 1 to 40 letters, digits, dashes, underscores, tildes or dots")
+	}
+	if req.Amount < 1 {
+		problems = append(problems, "amount must be at least 1")
+	}
+	if req.Currency != "IDR" {
+		problems = append(problems, "currency must be IDR")
+	}
+	switch req.PaymentType {
+	case "credit_card":
+	case "gopay":
+		if req.Gopay == nil || req.Gopay.AccountID == "" {
+			problems = append(problems, "gopay.account_id is required for gopay")
+		}
+	default:
+		problems = append(problems, "payment_type must be credit_card or gopay")
+	}
+	if req.Token == "" {
+		problems = append(problems, "token is required")
+	}
+	sched := req.Schedule
+	if sched.Interval < 1 {
+		problems = append(problems, "schedule.interval must be at least 1")
+	}
+	if sched.IntervalUnit != "day" && sched.IntervalUnit != "week" && sched.IntervalUnit != "month" {
+		problems = append(problems, "schedule.interval_unit must be day, week or month")
+	}
+	if sched.MaxInterval < 0 {
+		problems = append(problems, "schedule.max_interval must not be negative")
+	}
+	now := s.now()
+	start := now
+	if sched.StartTime != "" {
+		var err error
+		start, err = time.Parse(expiryTimeLayout, sched.StartTime)
+		if err != nil {
+			problems = append(problems, "schedule.start_time must be in yyyy-MM-dd HH:mm:ss Z format")
+		} else if !start.After(now) {
+			problems = append(problems, "schedule.start_time must be after the current time")
+		}
+	}
+	if len(problems) > 0 {
+		writeSubscriptionError(w, http.StatusBadRequest, problems...)
+		return
+	}
+
+	sub := &subscription{
+		ID:           uuid.NewString(),
+		Name:         req.Name,
+		Amount:       req.Amount,
+		PaymentType:  req.PaymentType,
+		Token:        req.Token,
+		Interval:     sched.Interval,
+		IntervalUnit: sched.IntervalUnit,
+		MaxInterval:  sched.MaxInterval,
+		StartTime:    start,
+		Status:       "active",
+		CreatedAt:    now,
+	}
+	// Without a start time Midtrans charges one interval from now.
+	sub.NextAt = start
+	if sched.StartTime == "" {
+		sub.NextAt = sub.next(now)
+	}
+
+	s.mu.Lock()
+	s.subscriptions[sub.ID] = sub
+	resp := newSubscriptionResponse(sub)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) getSubscription(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	sub, ok := s.subscriptions[r.PathValue("id")]
+	var resp subscriptionResponse
+	if ok {
+		resp = newSubscriptionResponse(sub)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeSubscriptionError(w, http.StatusNotFound, "Subscription doesn't exist.")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// setSubscriptionStatus handles disable, enable and cancel. Enabling moves
+// the next charge past any that were skipped while disabled.
+func (s *Server) setSubscriptionStatus(status string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		sub, ok := s.subscriptions[r.PathValue("id")]
+		cancelled := ok && sub.Status == "cancelled"
+		if ok && !cancelled {
+			sub.Status = status
+			if status == "active" {
+				now := s.now()
+				for !sub.NextAt.After(now) {
+					sub.NextAt = sub.next(sub.NextAt)
+				}
+			}
+		}
+		s.mu.Unlock()
+
+		switch {
+		case !ok:
+			writeSubscriptionError(w, http.StatusNotFound, "Subscription doesn't exist.")
+		case cancelled:
+			writeSubscriptionError(w, http.StatusBadRequest, "Subscription is already cancelled.")
+		default:
+			writeJSON(w, http.StatusOK, map[string]string{"status_message": "Subscription is updated."})
+		}
+	}
+}
+
+// ChargeSubscription runs the subscription's next scheduled charge now,
+// ending in status (settlement when empty), and delivers its notification
+// before returning. It returns the charge's order ID.
+func (s *Server) ChargeSubscription(id, status string) (string, error) {
+	if status == "" {
+		status = "settlement"
+	}
+	if !validStatus(status) {
+		return "", fmt.Errorf("emulator: unknown transaction_status %q", status)
+	}
+
+	s.mu.Lock()
+	sub, ok := s.subscriptions[id]
+	if !ok {
+		s.mu.Unlock()
+		return "", ErrUnknownSubscription
+	}
+	if sub.Status != "active" || sub.finished() {
+		s.mu.Unlock()
+		return "", errSubscriptionInactive
+	}
+	now := s.now()
+	sub.Attempts++
+	if status == "capture" || status == "settlement" {
+		sub.CurrentInterval++
+	}
+	sub.PreviousAt = now
+	sub.NextAt = sub.next(sub.NextAt)
+	t := &transaction{
+		OrderID:       fmt.Sprintf("%s-%d", sub.Name, sub.Attempts),
+		Token:         uuid.NewString(),
+		TransactionID: uuid.NewString(),
+		GrossAmount:   sub.Amount,
+	}
+	fraudStatus := ""
+	if status == "capture" || status == "settlement" {
+		fraudStatus = "accept"
+	}
+	t.setStatus(status, sub.PaymentType, fraudStatus, now)
+	s.transactions[t.OrderID] = t
+	s.byToken[t.Token] = t.OrderID
+	n := s.notification(t)
+	s.mu.Unlock()
+
+	_, err := s.deliver(n)
+	return t.OrderID, err
+}
+
+// chargeSubscription is the HTTP form of ChargeSubscription.
+func (s *Server) chargeSubscription(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TransactionStatus string `json:"transaction_status"`
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		writeCoreError(w, http.StatusBadRequest, "request body is not valid JSON")
+		return
+	}
+
+	orderID, err := s.ChargeSubscription(r.PathValue("id"), req.TransactionStatus)
+	switch {
+	case errors.Is(err, ErrUnknownSubscription):
+		writeCoreError(w, http.StatusNotFound, "Subscription doesn't exist.")
+		return
+	case errors.Is(err, errSubscriptionInactive):
+		writeCoreError(w, http.StatusConflict, "Subscription is not active.")
+		return
+	case orderID == "":
+		writeCoreError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	result := map[string]any{"order_id": orderID}
+	if err != nil {
+		result["notification_error"] = err.Error()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,157 @@
+package emulator
+
+import (
+	"errors"
+	"time"
+)
+
+type transaction struct {
+	OrderID       string
+	Token         string
+	TransactionID string
+	GrossAmount   int64
+	// FinishURL is the callbacks.finish the transaction was created with.
+	FinishURL string
+	// SaveCard is credit_card.save_card: a card payment issues a saved
+	// token for Card.
+	SaveCard bool
+	Card     *savedCard
+	// Status is empty until the customer picks a payment method; until
+	// then the Core API does not know the transaction.
+	Status          string
+	PaymentType     string
+	FraudStatus     string
+	TransactionTime time.Time
+	SettlementTime  time.Time
+	Refunds         []refund
+}
+
+// savedCard is a card saved with a Snap payment, charged again by its token.
+type savedCard struct {
+	Token      string
+	ExpiresAt  time.Time
+	MaskedCard string
+	Bank       string
+	CardType   string
+}
+
+type refund struct {
+	Key        string
+	Amount     int64
+	Reason     string
+	Chargeback int
+	CreatedAt  time.Time
+}
+
+var (
+	errNotStarted    = errors.New("transaction doesn't exist")
+	errNotModifiable = errors.New("merchant cannot modify the status of the transaction")
+	errRefundTooBig  = errors.New("refund amount exceeds the remaining amount")
+)
+
+// statusCodes are the status_code values Midtrans sends for each
+// transaction status.
+var statusCodes = map[string]string{
+	"pending":        "201",
+	"capture":        "200",
+	"settlement":     "200",
+	"deny":           "202",
+	"cancel":         "200",
+	"expire":         "407",
+	"failure":        "202",
+	"refund":         "200",
+	"partial_refund": "200",
+}
+
+func validStatus(status string) bool {
+	_, ok := statusCodes[status]
+	return ok
+}
+
+func (t *transaction) started() bool {
+	return t.Status != ""
+}
+
+func (t *transaction) refunded() int64 {
+	var total int64
+	for _, r := range t.Refunds {
+		total += r.Amount
+	}
+	return total
+}
+
+func (t *transaction) findRefund(key string) *refund {
+	for i := range t.Refunds {
+		if t.Refunds[i].Key == key {
+			return &t.Refunds[i]
+		}
+	}
+	return nil
+}
+
+func (t *transaction) cancel() error {
+	if !t.started() {
+		return errNotStarted
+	}
+	if t.Status != "pending" && t.Status != "capture" {
+		return errNotModifiable
+	}
+	t.Status = "cancel"
+	return nil
+}
+
+// refund records a refund, returning the existing one when key was used
+// before. amount 0 refunds whatever is left.
+func (t *transaction) refund(key string, amount int64, reason string, now time.Time) (*refund, error) {
+	if !t.started() {
+		return nil, errNotStarted
+	}
+	if key != "" {
+		if existing := t.findRefund(key); existing != nil {
+			return existing, nil
+		}
+	}
+	if t.Status != "settlement" && t.Status != "partial_refund" {
+		return nil, errNotModifiable
+	}
+
+	remaining := t.GrossAmount - t.refunded()
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		return nil, errRefundTooBig
+	}
+
+	t.Refunds = append(t.Refunds, refund{
+		Key:        key,
+		Amount:     amount,
+		Reason:     reason,
+		Chargeback: len(t.Refunds) + 1,
+		CreatedAt:  now,
+	})
+	if amount == remaining {
+		t.Status = "refund"
+	} else {
+		t.Status = "partial_refund"
+	}
+	return &t.Refunds[len(t.Refunds)-1], nil
+}
+
+// setStatus moves the transaction to status as if the customer or the
+// payment network had acted on it.
+func (t *transaction) setStatus(status, paymentType, fraudStatus string, now time.Time) {
+	if !t.started() {
+		t.TransactionTime = now
+	}
+	t.Status = status
+	if paymentType != "" {
+		t.PaymentType = paymentType
+	}
+	if fraudStatus != "" {
+		t.FraudStatus = fraudStatus
+	}
+	if status == "settlement" && t.SettlementTime.IsZero() {
+		t.SettlementTime = now
+	}
+}
@@ -0,0 +1,89 @@
+package midtrans
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/midtrans/midtrans-go"
+
+	"payment-service-iae/gateway"
+)
+
+const providerName = "midtrans"
+
+// wrapError converts the SDK error into a *gateway.Error. It returns a nil
+// error interface for a nil pointer, avoiding the typed-nil trap of
+// returning *midtrans.Error as error directly.
+func wrapError(err *midtrans.Error) error {
+	if err == nil {
+		return nil
+	}
+
+	e := &gateway.Error{Provider: providerName, StatusCode: err.GetStatusCode(), Err: err}
+	if raw := err.GetRawApiResponse(); raw != nil {
+		e.Messages = parseMessages(raw.RawBody)
+	}
+	if len(e.Messages) == 0 && err.GetMessage() != "" && err.GetRawApiResponse() == nil {
+		e.Messages = []string{err.GetMessage()}
+	}
+	e.Kind = classify(e.StatusCode, e.Messages)
+	return e
+}
+
+func classify(status int, messages []string) gateway.ErrorKind {
+	for _, m := range messages {
+		m = strings.ToLower(m)
+		if strings.Contains(m, "order_id has already been taken") ||
+			strings.Contains(m, "order_id sudah digunakan") {
+			return gateway.KindDuplicateOrder
+		}
+	}
+
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return gateway.KindAuthentication
+	case status == http.StatusNotFound:
+		return gateway.KindNotFound
+	case status == http.StatusNotAcceptable || status == http.StatusConflict:
+		// Core API answers 406 for an order ID that was already used.
+		return gateway.KindDuplicateOrder
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return gateway.KindTimeout
+	case status == http.StatusTooManyRequests:
+		return gateway.KindRateLimited
+	case status == http.StatusBadRequest || status == http.StatusUnprocessableEntity:
+		return gateway.KindValidation
+	case status == 0 || status >= 500:
+		return gateway.KindUnavailable
+	}
+	return gateway.KindUnknown
+}
+
+// parseMessages pulls error_messages (Snap), validation_messages and
+// status_message (Core API) or validation_message (Subscription API) out of
+// a Midtrans error body.
+func parseMessages(body []byte) []string {
+	var payload struct {
+		ErrorMessages      []string `json:"error_messages"`
+		ValidationMessages []string `json:"validation_messages"`
+		ValidationMessage  []string `json:"validation_message"`
+		StatusMessage      string   `json:"status_message"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil
+	}
+	if len(payload.ErrorMessages) > 0 {
+		return payload.ErrorMessages
+	}
+	if len(payload.ValidationMessages) > 0 {
+		return payload.ValidationMessages
+	}
+	if len(payload.ValidationMessage) > 0 {
+		return payload.ValidationMessage
+	}
+	if payload.StatusMessage != "" {
+		return []string{payload.StatusMessage}
+	}
+	return nil
+}
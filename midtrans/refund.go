@@ -0,0 +1,74 @@
+package midtrans
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+
+	"github.com/midtrans/midtrans-go/coreapi"
+
+	"payment-service-iae/gateway"
+)
+
+// Refund returns money from a settled transaction. Midtrans treats the
+// refund key as an idempotency key, so retrying with the same key never
+// refunds twice.
+func (c *Client) Refund(ctx context.Context, req gateway.RefundRequest) (*gateway.RefundResult, error) {
+	amount, err := rupiah(req.Amount)
+	if err != nil {
+		return nil, err
+	}
+	resp, midtransErr := c.coreClient.RefundTransaction(url.PathEscape(req.OrderID), &coreapi.RefundReq{
+		RefundKey: req.RefundKey,
+		Amount:    amount,
+		Reason:    req.Reason,
+	})
+	if err := wrapError(midtransErr); err != nil {
+		return nil, err
+	}
+
+	refunded := req.Amount
+	if resp.RefundAmount != "" {
+		if a, err := ParseAmount(resp.RefundAmount); err == nil {
+			refunded = a
+		}
+	}
+
+	return &gateway.RefundResult{
+		RefundKey: req.RefundKey,
+		RefundID:  refundID(resp.RefundChargebackUUID, resp.RefundChargebackID),
+		Amount:    refunded,
+	}, nil
+}
+
+// refundID prefers the chargeback UUID Midtrans gives newer refunds.
+func refundID(uuid string, id int) string {
+	if uuid != "" {
+		return uuid
+	}
+	if id != 0 {
+		return strconv.Itoa(id)
+	}
+	return ""
+}
+
+// Cancel cancels a transaction that has not settled yet and returns its
+// resulting status.
+func (c *Client) Cancel(ctx context.Context, orderID string) (*gateway.TransactionStatus, error) {
+	resp, midtransErr := c.coreClient.CancelTransaction(url.PathEscape(orderID))
+	if err := wrapError(midtransErr); err != nil {
+		return nil, err
+	}
+
+	status, ok := ParseStatus(resp.TransactionStatus)
+	if !ok {
+		status = gateway.StatusCancel
+	}
+	return &gateway.TransactionStatus{
+		OrderID:       resp.OrderID,
+		TransactionID: resp.TransactionID,
+		Status:        status,
+		FraudStatus:   resp.FraudStatus,
+		PaymentType:   resp.PaymentType,
+	}, nil
+}
@@ -0,0 +1,162 @@
+package midtrans
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/midtrans/midtrans-go/coreapi"
+
+	"payment-service-iae/gateway"
+	"payment-service-iae/money"
+)
+
+// Midtrans reports times in Western Indonesia Time.
+var wib = time.FixedZone("WIB", 7*60*60)
+
+// statusResponse adds the saved card token, which the SDK's response type
+// leaves out.
+type statusResponse struct {
+	coreapi.TransactionStatusResponse
+	SavedTokenID          string `json:"saved_token_id"`
+	SavedTokenIDExpiredAt string `json:"saved_token_id_expired_at"`
+}
+
+// Status fetches the current state of orderID from the Core API
+// transaction-status endpoint.
+func (c *Client) Status(ctx context.Context, orderID string) (*gateway.TransactionStatus, error) {
+	resp := &statusResponse{}
+	midtransErr := c.coreClient.HttpClient.Call(
+		http.MethodGet,
+		fmt.Sprintf("%s/v2/%s/status", c.coreClient.Env.BaseUrl(), url.PathEscape(orderID)),
+		&c.coreClient.ServerKey,
+		nil,
+		nil,
+		resp,
+	)
+	if err := wrapError(midtransErr); err != nil {
+		return nil, err
+	}
+
+	status, ok := ParseStatus(resp.TransactionStatus)
+	if !ok {
+		return nil, fmt.Errorf("unknown Midtrans transaction_status %q for %s", resp.TransactionStatus, orderID)
+	}
+	amount, err := ParseAmount(resp.GrossAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &gateway.TransactionStatus{
+		OrderID:       resp.OrderID,
+		TransactionID: resp.TransactionID,
+		Status:        status,
+		FraudStatus:   resp.FraudStatus,
+		PaymentType:   resp.PaymentType,
+		GrossAmount:   amount,
+	}
+	if resp.SettlementTime != "" {
+		t, err := ParseTime(resp.SettlementTime)
+		if err != nil {
+			return nil, err
+		}
+		result.SettledAt = &t
+	}
+	if result.SavedCard, err = savedCard(resp.SavedTokenID, resp.SavedTokenIDExpiredAt, resp.MaskedCard, resp.CardType, resp.Bank); err != nil {
+		return nil, err
+	}
+	for _, rf := range resp.Refunds {
+		refunded, err := ParseAmount(rf.RefundAmount)
+		if err != nil {
+			return nil, err
+		}
+		result.Refunds = append(result.Refunds, gateway.RefundResult{
+			RefundKey: rf.RefundKey,
+			RefundID:  refundID(rf.RefundChargebackUUID, rf.RefundChargebackID),
+			Amount:    refunded,
+		})
+	}
+	return result, nil
+}
+
+// savedCard is the card Midtrans saved with a transaction, or nil when it
+// saved none.
+func savedCard(token, expiresAt, maskedCard, cardType, bank string) (*gateway.SavedCard, error) {
+	if token == "" {
+		return nil, nil
+	}
+	card := &gateway.SavedCard{Token: token, MaskedCard: maskedCard, CardType: cardType, Bank: bank}
+	if expiresAt != "" {
+		t, err := ParseTime(expiresAt)
+		if err != nil {
+			return nil, err
+		}
+		card.ExpiresAt = &t
+	}
+	return card, nil
+}
+
+// ParseStatus reads a Midtrans transaction_status, which spells failed as
+// failure.
+func ParseStatus(s string) (gateway.Status, bool) {
+	switch st := gateway.Status(s); st {
+	case gateway.StatusPending, gateway.StatusCapture, gateway.StatusSettlement, gateway.StatusDeny,
+		gateway.StatusCancel, gateway.StatusExpire, gateway.StatusRefund, gateway.StatusPartialRefund:
+		return st, true
+	case "failure":
+		return gateway.StatusFailed, true
+	}
+	return "", false
+}
+
+// ParseAmount converts Midtrans' decimal string ("150000.00") to whole
+// rupiah. IDR has no minor unit, so a non-zero fraction is rejected.
+func ParseAmount(s string) (money.Money, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if strings.Trim(frac, "0") != "" {
+		return money.Money{}, fmt.Errorf("invalid gross_amount %q", s)
+	}
+	amount, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || amount <= 0 {
+		return money.Money{}, fmt.Errorf("invalid gross_amount %q", s)
+	}
+	return money.Rupiah(amount), nil
+}
+
+// rupiah is m as the whole rupiah Midtrans takes; it charges nothing but
+// IDR.
+func rupiah(m money.Money) (int64, error) {
+	if m.Currency != money.IDR {
+		return 0, fmt.Errorf("midtrans only charges IDR, got %s", m)
+	}
+	return m.Amount, nil
+}
+
+// checkItems rejects item details that do not add up to amount, which
+// Midtrans requires when items are given.
+func checkItems(items []gateway.Item, total func() (money.Money, error), amount money.Money) error {
+	if len(items) == 0 {
+		return nil
+	}
+	sum, err := total()
+	if err != nil {
+		return fmt.Errorf("item details total: %w", err)
+	}
+	if sum != amount {
+		return fmt.Errorf("item details total %s does not match gross amount %s", sum, amount)
+	}
+	return nil
+}
+
+// ParseTime parses a Midtrans timestamp ("2024-01-31 15:04:05", WIB).
+func ParseTime(s string) (time.Time, error) {
+	t, err := time.ParseInLocation(time.DateTime, s, wib)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+	}
+	return t, nil
+}
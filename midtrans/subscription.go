@@ -0,0 +1,124 @@
+package midtrans
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/midtrans/midtrans-go/coreapi"
+
+	"payment-service-iae/gateway"
+	"payment-service-iae/money"
+)
+
+var _ gateway.Subscriptions = (*Client)(nil)
+
+// subscriptionReq is the SDK request with max_interval left out when zero.
+// The SDK always sends it, which would give plans that run until cancelled
+// a limit of 0.
+type subscriptionReq struct {
+	coreapi.SubscriptionReq
+	Schedule scheduleDetails `json:"schedule"`
+}
+
+type scheduleDetails struct {
+	coreapi.ScheduleDetails
+	MaxInterval int `json:"max_interval,omitempty"`
+}
+
+// CreateSubscription registers a recurring charge with the Subscription
+// API. Midtrans names each charge's order ID after req.Name.
+func (c *Client) CreateSubscription(ctx context.Context, sub gateway.SubscriptionRequest) (*gateway.SubscriptionResult, error) {
+	amount, err := rupiah(sub.Amount)
+	if err != nil {
+		return nil, err
+	}
+	req := subscriptionReq{
+		SubscriptionReq: coreapi.SubscriptionReq{
+			Name:            sub.Name,
+			Amount:          amount,
+			Currency:        string(money.IDR),
+			PaymentType:     coreapi.SubscriptionPaymentType(sub.PaymentType),
+			Token:           sub.Token,
+			CustomerDetails: toCustomerDetails(sub.Customer),
+		},
+		Schedule: scheduleDetails{
+			ScheduleDetails: coreapi.ScheduleDetails{
+				Interval:     sub.Interval,
+				IntervalUnit: sub.IntervalUnit,
+			},
+			MaxInterval: sub.MaxInterval,
+		},
+	}
+	if !sub.StartAt.IsZero() {
+		req.Schedule.StartTime = sub.StartAt.Format(expiryTimeLayout)
+	}
+	if sub.GopayAccountID != "" {
+		req.Gopay = &coreapi.GopaySubscriptionDetails{AccountId: sub.GopayAccountID}
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &coreapi.CreateSubscriptionResponse{}
+	if err := c.callSubscriptions(http.MethodPost, "", bytes.NewBuffer(body), resp); err != nil {
+		log.Printf("Midtrans API error: %v", err)
+		return nil, err
+	}
+
+	result, err := toSubscriptionResult(resp)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Midtrans subscription created: %s", resp.ID)
+	return result, nil
+}
+
+func (c *Client) PauseSubscription(ctx context.Context, id string) error {
+	_, midtransErr := c.coreClient.DisableSubscription(url.PathEscape(id))
+	return wrapError(midtransErr)
+}
+
+// ResumeSubscription enables the subscription and reads back its schedule,
+// which Midtrans moves forward past the charges skipped while paused.
+func (c *Client) ResumeSubscription(ctx context.Context, id string) (*gateway.SubscriptionResult, error) {
+	if _, midtransErr := c.coreClient.EnableSubscription(url.PathEscape(id)); midtransErr != nil {
+		return nil, wrapError(midtransErr)
+	}
+	resp, midtransErr := c.coreClient.GetSubscription(url.PathEscape(id))
+	if err := wrapError(midtransErr); err != nil {
+		return nil, err
+	}
+	return toSubscriptionResult(resp)
+}
+
+// CancelSubscription calls the cancel endpoint, which the SDK does not wrap.
+func (c *Client) CancelSubscription(ctx context.Context, id string) error {
+	return c.callSubscriptions(http.MethodPost, "/"+url.PathEscape(id)+"/cancel", nil, &coreapi.UpdateSubscriptionResponse{})
+}
+
+// callSubscriptions sends a request to the Subscription API below
+// /v1/subscriptions with the SDK's HTTP client.
+func (c *Client) callSubscriptions(method, path string, body io.Reader, resp any) error {
+	endpoint := fmt.Sprintf("%s/v1/subscriptions%s", c.coreClient.Env.BaseUrl(), path)
+	return wrapError(c.coreClient.HttpClient.Call(method, endpoint, &c.coreClient.ServerKey, c.coreClient.Options, body, resp))
+}
+
+func toSubscriptionResult(resp *coreapi.CreateSubscriptionResponse) (*gateway.SubscriptionResult, error) {
+	result := &gateway.SubscriptionResult{ID: resp.ID}
+	if next := resp.Schedule.NextExecutionAt; next != "" {
+		t, err := time.Parse(expiryTimeLayout, next)
+		if err != nil {
+			return nil, fmt.Errorf("invalid next_execution_at %q", next)
+		}
+		result.NextChargeAt = &t
+	}
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package money
+
+import (
+	"errors"
+	"io"
+	"strconv"
+)
+
+// MarshalGQL writes m as the GraphQL Money scalar, a string such as
+// "IDR 150000".
+func (m Money) MarshalGQL(w io.Writer) {
+	io.WriteString(w, strconv.Quote(m.String()))
+}
+
+// UnmarshalGQL reads the GraphQL Money scalar. A bare number is refused:
+// the currency says what unit the number is in.
+func (m *Money) UnmarshalGQL(v any) error {
+	s, ok := v.(string)
+	if !ok {
+		return errors.New("money must be a string with a currency, such as \"IDR 150000\"")
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,200 @@
+// Package money carries amounts as whole minor units of an ISO 4217
+// currency, so amounts cannot silently overflow or be mixed across
+// currencies and units.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCurrencyMismatch = errors.New("amounts are in different currencies")
+	ErrOverflow         = errors.New("amount is too large")
+)
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+const IDR Currency = "IDR"
+
+// digits is how many decimals each supported currency's minor unit has.
+// Rupiah are charged whole, so IDR has none.
+var digits = map[Currency]int{
+	IDR:   0,
+	"JPY": 0,
+	"EUR": 2,
+	"MYR": 2,
+	"SGD": 2,
+	"USD": 2,
+}
+
+// ParseCurrency reads a supported currency code, in any case.
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if _, ok := digits[c]; !ok {
+		return "", fmt.Errorf("unsupported currency %q", s)
+	}
+	return c, nil
+}
+
+// Digits is the number of decimals of c's minor unit.
+func (c Currency) Digits() int {
+	return digits[c]
+}
+
+// Money is an amount in minor units of Currency: rupiah for IDR, cents for
+// USD. The zero Money has no currency and can be added to any amount.
+type Money struct {
+	Amount   int64
+	Currency Currency
+}
+
+func New(amount int64, currency Currency) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// Rupiah is amount whole rupiah.
+func Rupiah(amount int64) Money {
+	return New(amount, IDR)
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// Neg is -m. The most negative amount has no negation and stays as is,
+// which no real amount ever reaches.
+func (m Money) Neg() Money {
+	if m.Amount == math.MinInt64 {
+		return m
+	}
+	return Money{Amount: -m.Amount, Currency: m.Currency}
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	c, err := m.currencyWith(o)
+	if err != nil {
+		return Money{}, err
+	}
+	if (o.Amount > 0 && m.Amount > math.MaxInt64-o.Amount) || (o.Amount < 0 && m.Amount < math.MinInt64-o.Amount) {
+		return Money{}, fmt.Errorf("%w: %s + %s", ErrOverflow, m, o)
+	}
+	return Money{Amount: m.Amount + o.Amount, Currency: c}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.Amount == math.MinInt64 {
+		return Money{}, fmt.Errorf("%w: %s - %s", ErrOverflow, m, o)
+	}
+	return m.Add(o.Neg())
+}
+
+// Mul is m times n, e.g. a unit price times a quantity.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount != 0 && n != 0 {
+		p := m.Amount * n
+		if p/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+			return Money{}, fmt.Errorf("%w: %s × %d", ErrOverflow, m, n)
+		}
+		return Money{Amount: p, Currency: m.Currency}, nil
+	}
+	return Money{Currency: m.Currency}, nil
+}
+
+// Cmp compares m and o like cmp.Compare.
+func (m Money) Cmp(o Money) (int, error) {
+	if _, err := m.currencyWith(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// currencyWith is the currency of an operation on m and o.
+func (m Money) currencyWith(o Money) (Currency, error) {
+	switch {
+	case m.Currency == o.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.Amount == 0:
+		return o.Currency, nil
+	case o.Currency == "" && o.Amount == 0:
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, o.Currency)
+}
+
+// String writes m as its currency and the amount in major units with the
+// currency's decimals, e.g. "IDR 150000" or "USD 12.50".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+	d := m.Currency.Digits()
+	s := strconv.FormatInt(m.Amount, 10)
+	if d == 0 {
+		return string(m.Currency) + " " + s
+	}
+	sign := ""
+	if m.Amount < 0 {
+		sign, s = "-", s[1:]
+	}
+	if len(s) <= d {
+		s = strings.Repeat("0", d-len(s)+1) + s
+	}
+	return string(m.Currency) + " " + sign + s[:len(s)-d] + "." + s[len(s)-d:]
+}
+
+// Parse reads an amount written as String writes it. The amount may have
+// fewer decimals than the currency but not more, so "IDR 150000.50" is
+// rejected: there is no half rupiah to charge.
+func Parse(s string) (Money, error) {
+	code, amount, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q: want a currency and an amount, such as \"IDR 150000\"", s)
+	}
+	c, err := ParseCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	d := c.Digits()
+
+	amount = strings.TrimSpace(amount)
+	negative := strings.HasPrefix(amount, "-")
+	whole, frac, hasFrac := strings.Cut(strings.TrimPrefix(amount, "-"), ".")
+	if whole == "" || strings.ContainsAny(whole, "+-") || (hasFrac && frac == "") {
+		return Money{}, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(frac) > d {
+		if d == 0 {
+			return Money{}, fmt.Errorf("invalid amount %q: %s has no minor unit", s, c)
+		}
+		return Money{}, fmt.Errorf("invalid amount %q: %s has %d decimals", s, c, d)
+	}
+	digitsOnly := whole + frac + strings.Repeat("0", d-len(frac))
+	for _, r := range digitsOnly {
+		if r < '0' || r > '9' {
+			return Money{}, fmt.Errorf("invalid amount %q", s)
+		}
+	}
+	n, err := strconv.ParseInt(digitsOnly, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q", ErrOverflow, s)
+	}
+	if negative {
+		n = -n
+	}
+	return New(n, c), nil
+}
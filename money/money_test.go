@@ -0,0 +1,183 @@
+package money
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+const usd Currency = "USD"
+
+func TestAdd(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    Money
+		wantErr error
+	}{
+		{"sum", Rupiah(100), Rupiah(50), Rupiah(150), nil},
+		{"zero takes the other currency", Money{}, New(250, usd), New(250, usd), nil},
+		{"negative", Rupiah(100), Rupiah(-150), Rupiah(-50), nil},
+		{"largest amount", Rupiah(math.MaxInt64 - 1), Rupiah(1), Rupiah(math.MaxInt64), nil},
+		{"overflow", Rupiah(math.MaxInt64), Rupiah(1), Money{}, ErrOverflow},
+		{"underflow", Rupiah(math.MinInt64), Rupiah(-1), Money{}, ErrOverflow},
+		{"currency mismatch", Rupiah(100), New(100, usd), Money{}, ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Add(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s + %s: err = %v, want %v", tt.a, tt.b, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("%s + %s = %s, want %s", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSub(t *testing.T) {
+	tests := []struct {
+		name    string
+		a, b    Money
+		want    Money
+		wantErr error
+	}{
+		{"difference", Rupiah(100), Rupiah(30), Rupiah(70), nil},
+		{"below zero", Rupiah(30), Rupiah(100), Rupiah(-70), nil},
+		{"smallest amount", Rupiah(math.MinInt64 + 1), Rupiah(1), Rupiah(math.MinInt64), nil},
+		{"underflow", Rupiah(math.MinInt64), Rupiah(1), Money{}, ErrOverflow},
+		{"most negative subtrahend", Rupiah(0), Rupiah(math.MinInt64), Money{}, ErrOverflow},
+		{"overflow", Rupiah(math.MaxInt64), Rupiah(-1), Money{}, ErrOverflow},
+		{"currency mismatch", Rupiah(100), New(100, usd), Money{}, ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Sub(tt.b)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s - %s: err = %v, want %v", tt.a, tt.b, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("%s - %s = %s, want %s", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMul(t *testing.T) {
+	tests := []struct {
+		name    string
+		m       Money
+		n       int64
+		want    Money
+		wantErr error
+	}{
+		{"product", Rupiah(15000), 3, Rupiah(45000), nil},
+		{"by zero", Rupiah(math.MaxInt64), 0, Rupiah(0), nil},
+		{"negative", Rupiah(15000), -2, Rupiah(-30000), nil},
+		{"overflow", Rupiah(math.MaxInt64/2 + 1), 2, Money{}, ErrOverflow},
+		{"negative overflow", Rupiah(math.MaxInt64/2 + 1), -3, Money{}, ErrOverflow},
+		{"minus one times most negative", Rupiah(-1), math.MinInt64, Money{}, ErrOverflow},
+		{"most negative times minus one", Rupiah(math.MinInt64), -1, Money{}, ErrOverflow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.m.Mul(tt.n)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s × %d: err = %v, want %v", tt.m, tt.n, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("%s × %d = %s, want %s", tt.m, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCmp(t *testing.T) {
+	tests := []struct {
+		a, b    Money
+		want    int
+		wantErr error
+	}{
+		{Rupiah(1), Rupiah(2), -1, nil},
+		{Rupiah(2), Rupiah(1), 1, nil},
+		{Rupiah(2), Rupiah(2), 0, nil},
+		{Money{}, Rupiah(0), 0, nil},
+		{Rupiah(2), New(2, usd), 0, ErrCurrencyMismatch},
+	}
+	for _, tt := range tests {
+		got, err := tt.a.Cmp(tt.b)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("Cmp(%s, %s): err = %v, want %v", tt.a, tt.b, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("Cmp(%s, %s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestStringParse(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{Rupiah(150000), "IDR 150000"},
+		{Rupiah(-150000), "IDR -150000"},
+		{New(1250, usd), "USD 12.50"},
+		{New(5, usd), "USD 0.05"},
+		{New(-5, usd), "USD -0.05"},
+	}
+	for _, tt := range tests {
+		s := tt.m.String()
+		if s != tt.want {
+			t.Errorf("String() = %q, want %q", s, tt.want)
+		}
+		got, err := Parse(s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", s, err)
+			continue
+		}
+		if got != tt.m {
+			t.Errorf("Parse(%q) = %s, want %s", s, got, tt.m)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{"IDR 150000", Rupiah(150000), false},
+		{" IDR  150000 ", Rupiah(150000), false},
+		{"USD 12.5", New(1250, usd), false},
+		{"USD 12", New(1200, usd), false},
+		{"IDR 150000.50", Money{}, true},
+		{"USD 12.505", Money{}, true},
+		{"USD 12.", Money{}, true},
+		{"USD .5", Money{}, true},
+		{"IDR --5", Money{}, true},
+		{"IDR 1e5", Money{}, true},
+		{"150000", Money{}, true},
+		{"XXX 1", Money{}, true},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q): err = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseOverflow(t *testing.T) {
+	for _, in := range []string{"IDR 9223372036854775808", "USD 92233720368547758.08"} {
+		if _, err := Parse(in); !errors.Is(err, ErrOverflow) {
+			t.Errorf("Parse(%q): err = %v, want %v", in, err, ErrOverflow)
+		}
+	}
+}
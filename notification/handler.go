@@ -0,0 +1,150 @@
+package notification
+
+import (
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"payment-service-iae/gateway"
+	"payment-service-iae/midtrans"
+	"payment-service-iae/payment"
+	"payment-service-iae/subscription"
+)
+
+const maxBodyBytes = 1 << 20
+
+// Notification is the subset of the Midtrans HTTP notification payload the
+// service reads. Only the signed fields are trusted; the status applied is
+// the one the gateway reports.
+type Notification struct {
+	OrderID           string `json:"order_id"`
+	StatusCode        string `json:"status_code"`
+	GrossAmount       string `json:"gross_amount"`
+	SignatureKey      string `json:"signature_key"`
+	TransactionStatus string `json:"transaction_status"`
+	FraudStatus       string `json:"fraud_status"`
+}
+
+// Handler receives Midtrans payment notifications. Midtrans retries any
+// delivery that does not get a 2xx response, so only failures worth retrying
+// (storage errors) return 5xx; notifications that can never succeed get 4xx
+// and duplicates or stale updates are acknowledged with 200.
+//
+// The signature does not cover transaction_status or fraud_status, so a
+// replayed notification could claim any of them. A notification is only
+// taken as word that the transaction changed; the update applied is the
+// gateway's own transaction status.
+//
+// Subscription renewals come through here too: the gateway charges them on
+// its own, so the first notification for a renewal creates its payment.
+// What follows a payment being paid, such as saving its card, is left to
+// payment.Service's hooks, which the reconciler triggers the same way.
+type Handler struct {
+	serverKey     string
+	gateway       gateway.Gateway
+	payments      *payment.Service
+	subscriptions *subscription.Service
+}
+
+func NewHandler(serverKey string, gw gateway.Gateway, payments *payment.Service, subscriptions *subscription.Service) *Handler {
+	return &Handler{serverKey: serverKey, gateway: gw, payments: payments, subscriptions: subscriptions}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil {
+		http.Error(w, "failed to read body", http.StatusBadRequest)
+		return
+	}
+
+	var n Notification
+	if err := json.Unmarshal(body, &n); err != nil {
+		http.Error(w, "invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+	if n.OrderID == "" || n.StatusCode == "" || n.GrossAmount == "" || n.TransactionStatus == "" {
+		http.Error(w, "missing required fields", http.StatusBadRequest)
+		return
+	}
+
+	if !VerifySignature(n, h.serverKey) {
+		log.Printf("Rejected notification for %s: invalid signature", n.OrderID)
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+
+	status, err := h.gateway.Status(r.Context(), n.OrderID)
+	switch {
+	case gateway.IsKind(err, gateway.KindNotFound):
+		log.Printf("Rejected notification for %s: unknown to the gateway", n.OrderID)
+		http.Error(w, "unknown order", http.StatusNotFound)
+		return
+	case err != nil:
+		log.Printf("Failed to confirm notification for %s with the gateway: %v", n.OrderID, err)
+		http.Error(w, "gateway unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if s, _ := midtrans.ParseStatus(n.TransactionStatus); s != status.Status || n.FraudStatus != status.FraudStatus {
+		log.Printf("Notification for %s says %s/%s but the gateway reports %s/%s; applying the gateway's",
+			n.OrderID, n.TransactionStatus, n.FraudStatus, status.Status, status.FraudStatus)
+	}
+	update := payment.UpdateFromGateway(status)
+
+	p, changed, err := h.payments.ApplyStatusUpdate(r.Context(), n.OrderID, update)
+	if errors.Is(err, payment.ErrNotFound) {
+		p, changed, err = h.subscriptions.ApplyRenewal(r.Context(), n.OrderID, update)
+	}
+	switch {
+	case errors.Is(err, payment.ErrNotFound):
+		http.Error(w, "unknown order", http.StatusNotFound)
+		return
+	case errors.Is(err, payment.ErrAmountMismatch):
+		log.Printf("Rejected notification for %s: %v", n.OrderID, err)
+		http.Error(w, "gross amount mismatch", http.StatusBadRequest)
+		return
+	case errors.Is(err, payment.ErrInvalidTransition):
+		// Out-of-order retries and replays of older notifications land here.
+		// Acknowledge them so Midtrans stops resending, but change nothing.
+		log.Printf("Ignored notification for %s: %v", n.OrderID, err)
+		w.WriteHeader(http.StatusOK)
+		return
+	case err != nil:
+		log.Printf("Failed to apply notification for %s: %v", n.OrderID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case changed && p.FraudStatus == payment.FraudDeny:
+		log.Printf("Payment %s was denied by fraud detection", p.OrderID)
+	case changed:
+		log.Printf("Payment %s is now %s", p.OrderID, p.Status)
+	case p.Status == payment.StatusPending && p.FraudStatus == payment.FraudChallenge:
+		log.Printf("Payment %s is held for fraud review", p.OrderID)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// VerifySignature checks signature_key, which Midtrans computes as
+// SHA512(order_id + status_code + gross_amount + server key).
+func VerifySignature(n Notification, serverKey string) bool {
+	expected := Signature(n.OrderID, n.StatusCode, n.GrossAmount, serverKey)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(strings.ToLower(n.SignatureKey))) == 1
+}
+
+func Signature(orderID, statusCode, grossAmount, serverKey string) string {
+	sum := sha512.Sum512([]byte(orderID + statusCode + grossAmount + serverKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,49 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifySignature(t *testing.T) {
+	const serverKey = "SB-Mid-server-key"
+	valid := Notification{
+		OrderID:     "order-1",
+		StatusCode:  "200",
+		GrossAmount: "150000.00",
+	}
+	valid.SignatureKey = Signature(valid.OrderID, valid.StatusCode, valid.GrossAmount, serverKey)
+
+	tests := []struct {
+		name      string
+		edit      func(n *Notification)
+		serverKey string
+		want      bool
+	}{
+		{"valid", func(n *Notification) {}, serverKey, true},
+		{"uppercase hex", func(n *Notification) { n.SignatureKey = strings.ToUpper(n.SignatureKey) }, serverKey, true},
+		{"wrong server key", func(n *Notification) {}, "other-key", false},
+		{"tampered order", func(n *Notification) { n.OrderID = "order-2" }, serverKey, false},
+		{"tampered status code", func(n *Notification) { n.StatusCode = "201" }, serverKey, false},
+		{"tampered amount", func(n *Notification) { n.GrossAmount = "1.00" }, serverKey, false},
+		{"missing signature", func(n *Notification) { n.SignatureKey = "" }, serverKey, false},
+		{"truncated signature", func(n *Notification) { n.SignatureKey = n.SignatureKey[:64] }, serverKey, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := valid
+			tt.edit(&n)
+			if got := VerifySignature(n, tt.serverKey); got != tt.want {
+				t.Errorf("VerifySignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignature(t *testing.T) {
+	// SHA512("order-1" + "200" + "150000.00" + "key"), as Midtrans computes it.
+	const want = "e0760016b1539f00edc064536588ab5d1037696987289d61ba68f39b5c4d342fa225378fbbbc974a5620583a1cdc366d4837da1d0e6846b0f7cc9b1dfb7daa2f"
+	if got := Signature("order-1", "200", "150000.00", "key"); got != want {
+		t.Errorf("Signature = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+
+	"payment-service-iae/midtrans"
+	"payment-service-iae/payment"
+)
+
+// ReturnPath is where Midtrans sends the customer back to when the payment
+// was created with callback URLs.
+const ReturnPath = "/payments/return"
+
+// ReturnHandler forwards a customer coming back from Midtrans to the
+// payment's finish, error or pending URL. Midtrans appends order_id,
+// status_code and transaction_status to the finish URL; they are passed on
+// unchanged. The status only picks which page to show, so the storefront must
+// still confirm the payment through the API.
+type ReturnHandler struct {
+	payments *payment.Service
+}
+
+func NewReturnHandler(payments *payment.Service) *ReturnHandler {
+	return &ReturnHandler{payments: payments}
+}
+
+func (h *ReturnHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	query := r.URL.Query()
+	orderID := query.Get("order_id")
+	if orderID == "" {
+		http.Error(w, "missing order_id", http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.payments.Get(r.Context(), orderID)
+	if errors.Is(err, payment.ErrNotFound) || (err == nil && (p.Options == nil || p.Options.Callbacks == nil)) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to load payment %s for return redirect: %v", orderID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	status := p.Status
+	if s, ok := midtrans.ParseStatus(query.Get("transaction_status")); ok {
+		status = payment.StatusFromGateway(s)
+	}
+	target, err := url.Parse(p.Options.Callbacks.For(status))
+	if err != nil {
+		log.Printf("Invalid callback URL stored for %s: %v", orderID, err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	params := target.Query()
+	for _, key := range []string{"order_id", "status_code", "transaction_status"} {
+		if v := query.Get(key); v != "" {
+			params.Set(key, v)
+		}
+	}
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
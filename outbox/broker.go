@@ -0,0 +1,74 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// MemoryBroker is an in-process Broker: it keeps every published event and
+// hands it to subscribers synchronously. Intended for tests.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	events      []Event
+	subscribers []func(context.Context, Event) error
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{}
+}
+
+// Subscribe registers fn for every event published from now on. An error
+// from fn fails the publish, so the relay retries the event.
+func (b *MemoryBroker) Subscribe(fn func(context.Context, Event) error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, e Event) error {
+	b.mu.Lock()
+	subscribers := append([]func(context.Context, Event) error(nil), b.subscribers...)
+	b.mu.Unlock()
+
+	for _, fn := range subscribers {
+		if err := fn(ctx, e); err != nil {
+			return err
+		}
+	}
+
+	b.mu.Lock()
+	b.events = append(b.events, e)
+	b.mu.Unlock()
+	return nil
+}
+
+// Events returns everything published so far, in publish order.
+func (b *MemoryBroker) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Event(nil), b.events...)
+}
+
+// LogBroker only logs events. It stands in when no broker is configured so
+// the outbox still drains in local development.
+type LogBroker struct{}
+
+func (LogBroker) Publish(ctx context.Context, e Event) error {
+	log.Printf("Event %s %s for %s: %s", e.ID, e.Type, e.AggregateID, e.Payload)
+	return nil
+}
+
+// FanOut publishes every event to each broker in turn. An event counts as
+// published only when all of them accepted it, so a retry may repeat it
+// for brokers that already did; they must tolerate duplicates.
+type FanOut []Broker
+
+func (f FanOut) Publish(ctx context.Context, e Event) error {
+	for _, b := range f {
+		if err := b.Publish(ctx, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store intended for tests and local
+// development without PostgreSQL.
+type MemoryStore struct {
+	mu      sync.Mutex
+	pending []memoryEntry
+}
+
+type memoryEntry struct {
+	event       Event
+	nextAttempt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Add queues events; it is the in-memory counterpart of Write.
+func (s *MemoryStore) Add(events ...Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range events {
+		s.pending = append(s.pending, memoryEntry{event: e})
+	}
+}
+
+// Pending returns the events not yet published.
+func (s *MemoryStore) Pending() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	events := make([]Event, 0, len(s.pending))
+	for _, entry := range s.pending {
+		events = append(events, entry.event)
+	}
+	return events
+}
+
+func (s *MemoryStore) Process(ctx context.Context, limit int, publish func(Event) error) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []Event
+	seen := make(map[string]bool)
+	for _, entry := range s.pending {
+		if len(due) == limit {
+			break
+		}
+		// Only the oldest pending event of an aggregate may go out.
+		if seen[entry.event.AggregateID] {
+			continue
+		}
+		seen[entry.event.AggregateID] = true
+		if entry.nextAttempt.After(now) {
+			continue
+		}
+		due = append(due, entry.event)
+	}
+
+	published := 0
+	results := make(map[string]error)
+	for _, o := range publishInOrder(due, publish) {
+		results[o.event.ID] = o.err
+		if o.err == nil {
+			published++
+		}
+	}
+
+	kept := s.pending[:0]
+	for _, entry := range s.pending {
+		err, processed := results[entry.event.ID]
+		switch {
+		case !processed:
+			kept = append(kept, entry)
+		case err != nil:
+			entry.event.Attempts++
+			entry.nextAttempt = now.Add(retryDelay(entry.event.Attempts))
+			kept = append(kept, entry)
+		}
+	}
+	s.pending = kept
+	return published, nil
+}
@@ -0,0 +1,72 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSBroker publishes events to a JetStream stream on the subject
+// <prefix><event type>, e.g. "events.payment.settled". The event ID is sent
+// as Nats-Msg-Id, so JetStream drops redeliveries inside its duplicate
+// window and the relay's retries are not seen twice.
+type NATSBroker struct {
+	conn   *nats.Conn
+	js     jetstream.JetStream
+	prefix string
+}
+
+// NewNATSBroker connects to url and creates the stream if it does not exist
+// yet, capturing every subject under prefix.
+func NewNATSBroker(ctx context.Context, url, stream, prefix string) (*NATSBroker, error) {
+	conn, err := nats.Connect(url, nats.Name("payment-service"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connect to NATS at %s: %w", url, err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("open JetStream: %w", err)
+	}
+
+	_, err = js.Stream(ctx, stream)
+	if errors.Is(err, jetstream.ErrStreamNotFound) {
+		_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:       stream,
+			Subjects:   []string{prefix + ">"},
+			Storage:    jetstream.FileStorage,
+			Duplicates: 10 * time.Minute,
+		})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("prepare JetStream stream %s: %w", stream, err)
+	}
+
+	return &NATSBroker{conn: conn, js: js, prefix: prefix}, nil
+}
+
+func (b *NATSBroker) Publish(ctx context.Context, e Event) error {
+	data, err := e.Envelope()
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(b.prefix + e.Type)
+	msg.Data = data
+	msg.Header.Set("Event-Type", e.Type)
+	msg.Header.Set("Aggregate-Id", e.AggregateID)
+
+	if _, err := b.js.PublishMsg(ctx, msg, jetstream.WithMsgID(e.ID)); err != nil {
+		return fmt.Errorf("publish %s to NATS: %w", e.ID, err)
+	}
+	return nil
+}
+
+func (b *NATSBroker) Close() {
+	b.conn.Close()
+}
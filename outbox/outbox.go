@@ -0,0 +1,89 @@
+// Package outbox implements the transactional outbox: domain events are
+// written in the same database transaction as the state change they
+// describe, and a Relay later publishes them to a Broker. Delivery is
+// at-least-once, so consumers must de-duplicate on Event.ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Event struct {
+	ID string
+	// Type names what happened, e.g. "payment.settled".
+	Type string
+	// AggregateID identifies the entity the event belongs to (the order
+	// ID for payments). Events of one aggregate are published in order.
+	AggregateID string
+	Payload     json.RawMessage
+	CreatedAt   time.Time
+	// Attempts counts failed publish attempts so far.
+	Attempts int
+}
+
+func NewEvent(eventType, aggregateID string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("encode %s event for %s: %w", eventType, aggregateID, err)
+	}
+	return Event{
+		ID:          uuid.NewString(),
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// envelope is the wire format brokers publish.
+type envelope struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	Data        json.RawMessage `json:"data"`
+}
+
+// Envelope encodes the event as the JSON message consumers receive.
+func (e Event) Envelope() ([]byte, error) {
+	return json.Marshal(envelope{
+		ID:          e.ID,
+		Type:        e.Type,
+		AggregateID: e.AggregateID,
+		OccurredAt:  e.CreatedAt,
+		Data:        e.Payload,
+	})
+}
+
+// Broker delivers events to other services. Publish must return nil only
+// once the broker has durably accepted the event.
+type Broker interface {
+	Publish(ctx context.Context, e Event) error
+}
+
+// Store hands unpublished events to the relay.
+type Store interface {
+	// Process claims up to limit due events in creation order, calls
+	// publish for each and records the outcome: published events are
+	// marked done, failed ones are retried later with backoff. It returns
+	// how many events were published.
+	Process(ctx context.Context, limit int, publish func(Event) error) (int, error)
+}
+
+// retryDelay is the backoff after the given number of failed attempts:
+// 1s, 2s, 4s ... capped at 5 minutes.
+func retryDelay(attempts int) time.Duration {
+	const maxDelay = 5 * time.Minute
+	if attempts < 1 {
+		return time.Second
+	}
+	if attempts > 9 {
+		return maxDelay
+	}
+	return min(time.Second<<(attempts-1), maxDelay)
+}
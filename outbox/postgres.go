@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Write inserts events as part of the caller's transaction, so they are
+// committed or rolled back together with the change they describe.
+func Write(ctx context.Context, tx execer, events ...Event) error {
+	for _, e := range events {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO outbox_events (id, event_type, aggregate_id, payload, created_at)
+			VALUES ($1, $2, $3, $4, $5)`,
+			e.ID, e.Type, e.AggregateID, []byte(e.Payload), e.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("insert %s event for %s: %w", e.Type, e.AggregateID, err)
+		}
+	}
+	return nil
+}
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Process claims rows with FOR UPDATE SKIP LOCKED, so several relays can run
+// side by side without publishing the same event concurrently. An event is
+// only due once every earlier event of its aggregate has been published.
+func (s *PostgresStore) Process(ctx context.Context, limit int, publish func(Event) error) (int, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin outbox batch: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT e.id, e.event_type, e.aggregate_id, e.payload, e.created_at, e.attempts
+		FROM outbox_events e
+		WHERE e.published_at IS NULL AND e.next_attempt_at <= NOW()
+			AND NOT EXISTS (
+				SELECT 1 FROM outbox_events earlier
+				WHERE earlier.aggregate_id = e.aggregate_id
+					AND earlier.published_at IS NULL
+					AND earlier.seq < e.seq
+			)
+		ORDER BY e.seq
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("select outbox events: %w", err)
+	}
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Type, &e.AggregateID, &payload, &e.CreatedAt, &e.Attempts); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan outbox event: %w", err)
+		}
+		e.Payload = payload
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("select outbox events: %w", err)
+	}
+
+	published := 0
+	for _, o := range publishInOrder(events, publish) {
+		if o.err == nil {
+			_, err = tx.ExecContext(ctx, `UPDATE outbox_events SET published_at = NOW() WHERE id = $1`, o.event.ID)
+			published++
+		} else {
+			attempts := o.event.Attempts + 1
+			_, err = tx.ExecContext(ctx, `
+				UPDATE outbox_events
+				SET attempts = $2, last_error = $3, next_attempt_at = $4
+				WHERE id = $1`,
+				o.event.ID, attempts, o.err.Error(), time.Now().Add(retryDelay(attempts)),
+			)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("record outbox event %s: %w", o.event.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit outbox batch: %w", err)
+	}
+	return published, nil
+}
+
+type outcome struct {
+	event Event
+	err   error
+}
+
+// publishInOrder publishes events one by one. Once an event of an aggregate
+// fails, the aggregate's later events in the batch are left untouched so
+// consumers never see them before the failed one.
+func publishInOrder(events []Event, publish func(Event) error) []outcome {
+	blocked := make(map[string]bool)
+	var outcomes []outcome
+	for _, e := range events {
+		if blocked[e.AggregateID] {
+			continue
+		}
+		err := publish(e)
+		if err != nil {
+			blocked[e.AggregateID] = true
+		}
+		outcomes = append(outcomes, outcome{event: e, err: err})
+	}
+	return outcomes
+}
@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Relay moves events from the Store to the Broker.
+type Relay struct {
+	store     Store
+	broker    Broker
+	interval  time.Duration
+	batchSize int
+}
+
+func NewRelay(store Store, broker Broker, interval time.Duration, batchSize int) *Relay {
+	return &Relay{store: store, broker: broker, interval: interval, batchSize: batchSize}
+}
+
+// Run publishes due events every interval until ctx is done. A full batch is
+// followed straight away by the next one so a backlog drains quickly.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			n, err := r.RunOnce(ctx)
+			if err != nil {
+				log.Printf("Outbox relay: %v", err)
+			}
+			if err != nil || n < r.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce publishes one batch and returns how many events went out.
+func (r *Relay) RunOnce(ctx context.Context) (int, error) {
+	return r.store.Process(ctx, r.batchSize, func(e Event) error {
+		err := r.broker.Publish(ctx, e)
+		if err != nil {
+			log.Printf("Outbox relay: publishing %s (%s for %s) failed, attempt %d: %v", e.ID, e.Type, e.AggregateID, e.Attempts+1, err)
+		}
+		return err
+	})
+}
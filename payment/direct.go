@@ -0,0 +1,30 @@
+package payment
+
+import "slices"
+
+// DirectMethods are the methods that can be charged directly, without the
+// hosted payment page.
+var DirectMethods = []string{"bca_va", "bni_va", "bri_va", "permata_va", "qris", "gopay", "shopeepay"}
+
+// IsDirectMethod reports whether name is one of DirectMethods.
+func IsDirectMethod(name string) bool {
+	return slices.Contains(DirectMethods, name)
+}
+
+// IsEWallet reports whether name is an e-wallet, paid in the wallet's app.
+func IsEWallet(name string) bool {
+	return name == "gopay" || name == "shopeepay"
+}
+
+// Instructions tell the customer how to pay a direct charge. Which fields
+// are set depends on Method: a bank and VA number for virtual accounts, a
+// QR code for QRIS, and a deeplink (plus a QR code for GoPay) for
+// e-wallets. They are stored with the payment.
+type Instructions struct {
+	Method      string `json:"method"`
+	Bank        string `json:"bank,omitempty"`
+	VANumber    string `json:"va_number,omitempty"`
+	QRString    string `json:"qr_string,omitempty"`
+	QRImageURL  string `json:"qr_image_url,omitempty"`
+	DeeplinkURL string `json:"deeplink_url,omitempty"`
+}
@@ -0,0 +1,138 @@
+package payment
+
+import (
+	"time"
+
+	"payment-service-iae/outbox"
+)
+
+// Domain event types published through the outbox.
+const (
+	EventCreated           = "payment.created"
+	EventCaptured          = "payment.captured"
+	EventSettled           = "payment.settled"
+	EventDenied            = "payment.denied"
+	EventCancelled         = "payment.cancelled"
+	EventExpired           = "payment.expired"
+	EventFailed            = "payment.failed"
+	EventRefunded          = "payment.refunded"
+	EventPartiallyRefunded = "payment.partially_refunded"
+)
+
+// EventTypes lists every payment event type.
+var EventTypes = []string{
+	EventCreated, EventCaptured, EventSettled, EventDenied, EventCancelled,
+	EventExpired, EventFailed, EventRefunded, EventPartiallyRefunded,
+}
+
+var statusEvents = map[Status]string{
+	StatusCapture:       EventCaptured,
+	StatusSettlement:    EventSettled,
+	StatusDeny:          EventDenied,
+	StatusCancel:        EventCancelled,
+	StatusExpire:        EventExpired,
+	StatusFailed:        EventFailed,
+	StatusRefund:        EventRefunded,
+	StatusPartialRefund: EventPartiallyRefunded,
+}
+
+// EventPayload is the data of every payment event. Amounts are in minor
+// units of Currency, which for IDR are whole rupiah.
+type EventPayload struct {
+	OrderID        string          `json:"order_id"`
+	CustomerID     string          `json:"customer_id"`
+	Status         Status          `json:"status"`
+	PreviousStatus Status          `json:"previous_status,omitempty"`
+	Amount         int64           `json:"amount"`
+	RefundedAmount int64           `json:"refunded_amount"`
+	Currency       string          `json:"currency"`
+	PaymentType    string          `json:"payment_type,omitempty"`
+	TransactionID  string          `json:"transaction_id,omitempty"`
+	SettledAt      *time.Time      `json:"settled_at,omitempty"`
+	ExpiresAt      *time.Time      `json:"expires_at,omitempty"`
+	SubscriptionID string          `json:"subscription_id,omitempty"`
+	Items          []EventItem     `json:"items"`
+	Discounts      []EventDiscount `json:"discounts,omitempty"`
+	Tax            int64           `json:"tax,omitempty"`
+	Fee            int64           `json:"fee,omitempty"`
+	Refund         *EventRefund    `json:"refund,omitempty"`
+}
+
+type EventItem struct {
+	BookID    string `json:"book_id"`
+	Title     string `json:"title"`
+	UnitPrice int64  `json:"unit_price"`
+	Quantity  int32  `json:"quantity"`
+}
+
+type EventDiscount struct {
+	Code   string `json:"code"`
+	Name   string `json:"name"`
+	Amount int64  `json:"amount"`
+}
+
+// EventRefund describes the refund that triggered a refund event.
+type EventRefund struct {
+	RefundKey string `json:"refund_key"`
+	Amount    int64  `json:"amount"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// recordEvent queues an event describing p's current state. The repository
+// writes queued events in the same transaction as the payment itself.
+func (p *Payment) recordEvent(eventType string, previous Status, refund *Refund) error {
+	payload := EventPayload{
+		OrderID:        p.OrderID,
+		CustomerID:     p.CustomerID,
+		Status:         p.Status,
+		PreviousStatus: previous,
+		Amount:         p.Amount.Amount,
+		RefundedAmount: p.RefundedAmount().Amount,
+		Currency:       string(p.Amount.Currency),
+		PaymentType:    p.PaymentType,
+		TransactionID:  p.TransactionID,
+		SettledAt:      p.SettledAt,
+		ExpiresAt:      p.ExpiresAt,
+		SubscriptionID: p.SubscriptionID,
+		Tax:            p.Tax.Amount,
+		Fee:            p.Fee.Amount,
+		Items:          make([]EventItem, 0, len(p.Items)),
+	}
+	for _, item := range p.Items {
+		payload.Items = append(payload.Items, EventItem{
+			BookID:    item.BookID,
+			Title:     item.Title,
+			UnitPrice: item.UnitPrice.Amount,
+			Quantity:  item.Quantity,
+		})
+	}
+	for _, d := range p.Discounts {
+		payload.Discounts = append(payload.Discounts, EventDiscount{Code: d.Code, Name: d.Name, Amount: d.Amount.Amount})
+	}
+	if refund != nil {
+		payload.Refund = &EventRefund{RefundKey: refund.RefundKey, Amount: refund.Amount.Amount, Reason: refund.Reason}
+	}
+
+	e, err := outbox.NewEvent(eventType, p.OrderID, payload)
+	if err != nil {
+		return err
+	}
+	p.events = append(p.events, e)
+	return nil
+}
+
+// recordStatusEvent queues the event for a status change from previous.
+func (p *Payment) recordStatusEvent(previous Status) error {
+	eventType, ok := statusEvents[p.Status]
+	if !ok {
+		return nil
+	}
+	return p.recordEvent(eventType, previous, nil)
+}
+
+// takeEvents returns and clears the queued events.
+func (p *Payment) takeEvents() []outbox.Event {
+	events := p.events
+	p.events = nil
+	return events
+}
@@ -0,0 +1,44 @@
+package payment
+
+import (
+	"slices"
+	"time"
+)
+
+// Methods are the payment method names used to configure per-method
+// behaviour, matching the Midtrans Snap enabled_payments values.
+var Methods = []string{
+	"credit_card", "gopay", "shopeepay", "qris",
+	"bca_va", "bni_va", "bri_va", "permata_va", "echannel", "other_va",
+	"indomaret", "alfamart", "akulaku", "kredivo",
+}
+
+// IsMethod reports whether name is one of Methods.
+func IsMethod(name string) bool {
+	return slices.Contains(Methods, name)
+}
+
+// ExpiryPolicy decides how long a customer has to pay. ByMethod overrides
+// Default for individual payment methods.
+type ExpiryPolicy struct {
+	Default  time.Duration
+	ByMethod map[string]time.Duration
+}
+
+// For returns the expiry for a payment that may be paid with any of methods.
+// With several methods the longest window wins, so no offered method is cut
+// short; with none (the customer chooses at the gateway) Default applies.
+func (p ExpiryPolicy) For(methods ...string) time.Duration {
+	if len(methods) == 0 {
+		return p.Default
+	}
+	var longest time.Duration
+	for _, m := range methods {
+		d, ok := p.ByMethod[m]
+		if !ok {
+			d = p.Default
+		}
+		longest = max(longest, d)
+	}
+	return longest
+}
@@ -0,0 +1,143 @@
+package payment
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// StatusChange is published after a payment's stored status has changed.
+type StatusChange struct {
+	Payment  *Payment
+	Previous Status
+}
+
+// statusFeed fans status changes out to the watchers of one order in this
+// process.
+type statusFeed struct {
+	mu       sync.Mutex
+	watchers map[string]map[chan StatusChange]struct{}
+}
+
+// watchBuffer bounds how far a slow watcher may fall behind before changes
+// are dropped for it.
+const watchBuffer = 16
+
+func newStatusFeed() *statusFeed {
+	return &statusFeed{watchers: make(map[string]map[chan StatusChange]struct{})}
+}
+
+func (f *statusFeed) watch(ctx context.Context, orderID string) <-chan StatusChange {
+	ch := make(chan StatusChange, watchBuffer)
+
+	f.mu.Lock()
+	if f.watchers[orderID] == nil {
+		f.watchers[orderID] = make(map[chan StatusChange]struct{})
+	}
+	f.watchers[orderID][ch] = struct{}{}
+	f.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		f.mu.Lock()
+		delete(f.watchers[orderID], ch)
+		if len(f.watchers[orderID]) == 0 {
+			delete(f.watchers, orderID)
+		}
+		f.mu.Unlock()
+		close(ch)
+	}()
+	return ch
+}
+
+func (f *statusFeed) watched(orderID string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.watchers[orderID]) > 0
+}
+
+func (f *statusFeed) publish(c StatusChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for ch := range f.watchers[c.Payment.OrderID] {
+		select {
+		case ch <- c:
+		default:
+			log.Printf("Dropped status change for %s: watcher is not keeping up", c.Payment.OrderID)
+		}
+	}
+}
+
+// StatusListener reports the status changes stored by every instance that
+// shares a store, e.g. through PostgreSQL LISTEN/NOTIFY.
+type StatusListener interface {
+	// ListenStatus calls changed for each stored status change until ctx is
+	// done or the listener fails.
+	ListenStatus(ctx context.Context, changed func(orderID string, status, previous Status)) error
+}
+
+// statusListenRetry is how long RunStatusFeed waits before listening again.
+const statusListenRetry = 5 * time.Second
+
+// WatchStatus streams every status change of orderID until ctx is done,
+// when the channel is closed. Without ShareStatus only changes made through
+// this Service are seen.
+func (s *Service) WatchStatus(ctx context.Context, orderID string) <-chan StatusChange {
+	return s.feed.watch(ctx, orderID)
+}
+
+// ShareStatus feeds watchers from l instead of from this Service, so a
+// change stored by another instance reaches them too. Call it before the
+// service is used and run RunStatusFeed.
+func (s *Service) ShareStatus(l StatusListener) {
+	s.listener = l
+}
+
+// RunStatusFeed passes the changes ShareStatus's listener reports to
+// watchers until ctx is done, listening again after a failure. Changes
+// stored while it is not listening are not delivered.
+func (s *Service) RunStatusFeed(ctx context.Context) {
+	if s.listener == nil {
+		return
+	}
+	for {
+		err := s.listener.ListenStatus(ctx, func(orderID string, status, previous Status) {
+			s.relayStatus(ctx, orderID, status, previous)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Payment status feed stopped, listening again in %s: %v", statusListenRetry, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(statusListenRetry):
+		}
+	}
+}
+
+// relayStatus publishes a change the listener reported. The payment is
+// loaded afresh, so it is shown with the status of the change even if it
+// has moved on since.
+func (s *Service) relayStatus(ctx context.Context, orderID string, status, previous Status) {
+	if !s.feed.watched(orderID) {
+		return
+	}
+	p, err := s.repo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		log.Printf("Failed to load %s for its status change: %v", orderID, err)
+		return
+	}
+	p.Status = status
+	s.feed.publish(StatusChange{Payment: p, Previous: previous})
+}
+
+// publishStatus hands a change made through this Service to watchers,
+// unless they follow a shared listener, which reports it as well.
+func (s *Service) publishStatus(c StatusChange) {
+	if s.listener == nil {
+		s.feed.publish(c)
+	}
+}
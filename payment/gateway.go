@@ -0,0 +1,62 @@
+package payment
+
+import "payment-service-iae/gateway"
+
+// Package gateway has its own provider-neutral types so it does not depend
+// on payments; these are the only conversions between the two.
+
+// StatusFromGateway is the payment status for a gateway status. Payment
+// statuses mirror the gateway's.
+func StatusFromGateway(s gateway.Status) Status {
+	return Status(s)
+}
+
+// UpdateFromGateway is the update the gateway's view of a transaction
+// reports.
+func UpdateFromGateway(s *gateway.TransactionStatus) StatusUpdate {
+	u := StatusUpdate{
+		Status:        StatusFromGateway(s.Status),
+		GrossAmount:   s.GrossAmount,
+		TransactionID: s.TransactionID,
+		PaymentType:   s.PaymentType,
+		FraudStatus:   s.FraudStatus,
+		SettledAt:     s.SettledAt,
+	}
+	for _, r := range s.Refunds {
+		u.Refunds = append(u.Refunds, MadeRefund{RefundKey: r.RefundKey, RefundID: r.RefundID})
+	}
+	if c := s.SavedCard; c != nil {
+		u.SavedCard = &SavedCard{
+			Token:      c.Token,
+			ExpiresAt:  c.ExpiresAt,
+			MaskedCard: c.MaskedCard,
+			CardType:   c.CardType,
+			Bank:       c.Bank,
+		}
+	}
+	return u
+}
+
+// InstructionsFromGateway stores the gateway's payment instructions.
+func InstructionsFromGateway(in gateway.Instructions) *Instructions {
+	return &Instructions{
+		Method:      in.Method,
+		Bank:        in.Bank,
+		VANumber:    in.VANumber,
+		QRString:    in.QRString,
+		QRImageURL:  in.QRImageURL,
+		DeeplinkURL: in.DeeplinkURL,
+	}
+}
+
+// GatewayCardOptions are o for the gateway's hosted checkout.
+func GatewayCardOptions(o *CardOptions) *gateway.CardOptions {
+	if o == nil {
+		return nil
+	}
+	g := &gateway.CardOptions{SaveCard: o.SaveCard}
+	if in := o.Installment; in != nil {
+		g.Installment = &gateway.Installment{Required: in.Required, Terms: in.Terms}
+	}
+	return g
+}
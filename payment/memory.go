@@ -0,0 +1,188 @@
+package payment
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"payment-service-iae/outbox"
+)
+
+// MemoryRepository is an in-process Repository intended for tests and local
+// development without PostgreSQL.
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	payments   map[string]Payment
+	reconciled map[string]time.Time
+	outbox     *outbox.MemoryStore
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		payments:   make(map[string]Payment),
+		reconciled: make(map[string]time.Time),
+		outbox:     outbox.NewMemoryStore(),
+	}
+}
+
+// Outbox returns the store the repository writes events to.
+func (r *MemoryRepository) Outbox() *outbox.MemoryStore {
+	return r.outbox
+}
+
+func (r *MemoryRepository) Create(ctx context.Context, p *Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.payments[p.OrderID]; ok {
+		return ErrAlreadyExists
+	}
+
+	now := time.Now()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	r.outbox.Add(p.takeEvents()...)
+	r.payments[p.OrderID] = clonePayment(p)
+	return nil
+}
+
+func (r *MemoryRepository) GetByOrderID(ctx context.Context, orderID string) (*Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.payments[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	c := clonePayment(&p)
+	return &c, nil
+}
+
+func (r *MemoryRepository) Update(ctx context.Context, orderID string, fn func(p *Payment) error) (*Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.payments[orderID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	p := clonePayment(&stored)
+	if err := fn(&p); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p.UpdatedAt = now
+	for i := range p.Refunds {
+		rf := &p.Refunds[i]
+		if rf.CreatedAt.IsZero() {
+			rf.CreatedAt = now
+		}
+		// Like the refunds table, only a refund that changed is touched.
+		if old := stored.findRefund(rf.RefundKey); old == nil || old.Status != rf.Status || old.GatewayRefundID != rf.GatewayRefundID {
+			rf.UpdatedAt = now
+		}
+	}
+	r.outbox.Add(p.takeEvents()...)
+	r.payments[orderID] = clonePayment(&p)
+	return &p, nil
+}
+
+func (r *MemoryRepository) ClaimStalePending(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type candidate struct {
+		orderID string
+		since   time.Time
+	}
+	var due []candidate
+	for orderID, p := range r.payments {
+		since, ok := r.reconciled[orderID]
+		if !ok {
+			since = p.CreatedAt
+		}
+		if p.Status == StatusPending && since.Before(cutoff) {
+			due = append(due, candidate{orderID: orderID, since: since})
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].since.Before(due[j].since) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	now := time.Now()
+	orderIDs := make([]string, 0, len(due))
+	for _, c := range due {
+		r.reconciled[c.orderID] = now
+		orderIDs = append(orderIDs, c.orderID)
+	}
+	return orderIDs, nil
+}
+
+func (r *MemoryRepository) ListExpiredPending(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var expired []Payment
+	for _, p := range r.payments {
+		if p.Status == StatusPending && p.ExpiresAt != nil && p.ExpiresAt.Before(cutoff) {
+			expired = append(expired, p)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool { return expired[i].ExpiresAt.Before(*expired[j].ExpiresAt) })
+	if len(expired) > limit {
+		expired = expired[:limit]
+	}
+
+	orderIDs := make([]string, 0, len(expired))
+	for _, p := range expired {
+		orderIDs = append(orderIDs, p.OrderID)
+	}
+	return orderIDs, nil
+}
+
+func (r *MemoryRepository) ListStalePendingRefunds(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type candidate struct {
+		orderID string
+		since   time.Time
+	}
+	var due []candidate
+	for orderID, p := range r.payments {
+		var since time.Time
+		for _, rf := range p.Refunds {
+			if rf.Status == RefundPending && rf.UpdatedAt.Before(cutoff) && (since.IsZero() || rf.UpdatedAt.Before(since)) {
+				since = rf.UpdatedAt
+			}
+		}
+		if !since.IsZero() {
+			due = append(due, candidate{orderID: orderID, since: since})
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].since.Before(due[j].since) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	orderIDs := make([]string, 0, len(due))
+	for _, c := range due {
+		orderIDs = append(orderIDs, c.orderID)
+	}
+	return orderIDs, nil
+}
+
+// clonePayment copies p including its slices so callers never share memory
+// with the stored record.
+func clonePayment(p *Payment) Payment {
+	c := *p
+	c.Items = append([]Item(nil), p.Items...)
+	c.Discounts = append([]Discount(nil), p.Discounts...)
+	c.Refunds = append([]Refund(nil), p.Refunds...)
+	c.events = nil
+	return c
+}
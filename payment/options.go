@@ -0,0 +1,163 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const (
+	maxCustomFieldLength = 255
+	maxInstallmentMonths = 36
+)
+
+// InstallmentBanks are the acquiring banks Midtrans offers card installments
+// through; offline covers installments run by the issuing bank.
+var InstallmentBanks = []string{"bni", "mandiri", "cimb", "mega", "bca", "bri", "maybank", "offline"}
+
+// Options are the checkout choices a client may make when creating a
+// payment. They are stored with the payment.
+type Options struct {
+	// PaymentMethods limits the methods offered to the customer; empty
+	// offers every method enabled for the merchant.
+	PaymentMethods []string     `json:"payment_methods,omitempty"`
+	Callbacks      *Callbacks   `json:"callbacks,omitempty"`
+	CustomFields   []string     `json:"custom_fields,omitempty"`
+	CreditCard     *CardOptions `json:"credit_card,omitempty"`
+}
+
+// Callbacks are where the customer is sent back to after paying.
+type Callbacks struct {
+	FinishURL  string `json:"finish_url,omitempty"`
+	ErrorURL   string `json:"error_url,omitempty"`
+	PendingURL string `json:"pending_url,omitempty"`
+}
+
+type CardOptions struct {
+	// SaveCard asks the gateway for a reusable token for the card.
+	SaveCard    bool         `json:"save_card,omitempty"`
+	Installment *Installment `json:"installment,omitempty"`
+}
+
+type Installment struct {
+	// Required makes the customer pay in installments.
+	Required bool `json:"required,omitempty"`
+	// Terms maps a bank in InstallmentBanks to the month counts offered.
+	Terms map[string][]int `json:"terms"`
+}
+
+// URLs returns the callback URLs that are set.
+func (c *Callbacks) URLs() []string {
+	var urls []string
+	for _, u := range []string{c.FinishURL, c.ErrorURL, c.PendingURL} {
+		if u != "" {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// For picks the page for a customer returning with a payment in status s,
+// falling back to FinishURL, which is always set.
+func (c *Callbacks) For(s Status) string {
+	target := c.FinishURL
+	switch s {
+	case StatusPending:
+		target = c.PendingURL
+	case StatusDeny, StatusCancel, StatusExpire, StatusFailed:
+		target = c.ErrorURL
+	}
+	if target == "" {
+		return c.FinishURL
+	}
+	return target
+}
+
+// Validate checks the options are well-formed, independent of who set them.
+func (o *Options) Validate() error {
+	var problems []string
+	seen := make(map[string]bool)
+	for _, m := range o.PaymentMethods {
+		if !IsMethod(m) {
+			problems = append(problems, fmt.Sprintf("unknown payment method %q", m))
+		} else if seen[m] {
+			problems = append(problems, fmt.Sprintf("payment method %q is listed twice", m))
+		}
+		seen[m] = true
+	}
+
+	if o.Callbacks != nil {
+		if o.Callbacks.FinishURL == "" {
+			problems = append(problems, "callbacks need a finish URL")
+		}
+		for _, raw := range o.Callbacks.URLs() {
+			if u, err := url.Parse(raw); err != nil || u.Scheme != "https" || u.Host == "" {
+				problems = append(problems, fmt.Sprintf("callback URL %q must be an absolute https URL", raw))
+			}
+		}
+	}
+
+	if len(o.CustomFields) > 3 {
+		problems = append(problems, "at most 3 custom fields are allowed")
+	}
+	for i, f := range o.CustomFields {
+		if len(f) > maxCustomFieldLength {
+			problems = append(problems, fmt.Sprintf("custom field %d is longer than %d characters", i+1, maxCustomFieldLength))
+		}
+	}
+
+	if o.CreditCard != nil {
+		if len(o.PaymentMethods) > 0 && !seen["credit_card"] {
+			problems = append(problems, "credit card options need credit_card among the payment methods")
+		}
+		if in := o.CreditCard.Installment; in != nil {
+			problems = append(problems, in.problems()...)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &OptionsError{Problems: problems}
+	}
+	return nil
+}
+
+func (in *Installment) problems() []string {
+	var problems []string
+	if len(in.Terms) == 0 {
+		problems = append(problems, "installment needs terms for at least one bank")
+	}
+	for bank, months := range in.Terms {
+		if !slices.Contains(InstallmentBanks, bank) {
+			problems = append(problems, fmt.Sprintf("unknown installment bank %q (known: %s)", bank, strings.Join(InstallmentBanks, ", ")))
+		}
+		if len(months) == 0 {
+			problems = append(problems, fmt.Sprintf("installment terms for %s are empty", bank))
+		}
+		for _, m := range months {
+			if m < 2 || m > maxInstallmentMonths {
+				problems = append(problems, fmt.Sprintf("installment term %d for %s must be between 2 and %d months", m, bank, maxInstallmentMonths))
+			}
+		}
+	}
+	return problems
+}
+
+// OptionsError lists what is wrong with a set of options.
+type OptionsError struct {
+	Problems []string
+	// NotAllowed is set when the options are well-formed but the client may
+	// not use them.
+	NotAllowed bool
+}
+
+func (e *OptionsError) Error() string {
+	return "payment options: " + strings.Join(e.Problems, "; ")
+}
+
+// IsOptionsNotAllowed reports whether err is a policy refusal.
+func IsOptionsNotAllowed(err error) bool {
+	var e *OptionsError
+	return errors.As(err, &e) && e.NotAllowed
+}
@@ -0,0 +1,119 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-service-iae/money"
+	"payment-service-iae/outbox"
+)
+
+var (
+	ErrNotFound      = errors.New("payment not found")
+	ErrAlreadyExists = errors.New("payment already exists")
+)
+
+type Payment struct {
+	OrderID    string
+	BookID     string
+	CustomerID string
+	// Amount is what the customer is charged. Every other amount on the
+	// payment is in its currency.
+	Amount         money.Money
+	SnapToken      string
+	RedirectURL    string
+	Status         Status
+	TransactionID  string
+	PaymentType    string
+	FraudStatus    string
+	SettledAt      *time.Time
+	ExpiresAt      *time.Time
+	Options        *Options
+	Instructions   *Instructions
+	SubscriptionID string
+	Items          []Item
+	// Discounts lower Amount below the sum of Items; Tax and Fee are added
+	// on top. See Breakdown.
+	Discounts []Discount
+	TaxRate   Rate
+	Tax       money.Money
+	Fee       money.Money
+	Refunds   []Refund
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// events are domain events waiting to be saved with the payment.
+	events []outbox.Event
+}
+
+// Item is one line of the order: a book, its unit price at checkout time
+// and how many were bought.
+type Item struct {
+	BookID    string
+	Title     string
+	UnitPrice money.Money
+	Quantity  int32
+}
+
+// Subtotal is the unit price times the quantity. Orders are checked for
+// overflow when they are priced, so stored items cannot overflow.
+func (i Item) Subtotal() money.Money {
+	return money.New(i.UnitPrice.Amount*int64(i.Quantity), i.UnitPrice.Currency)
+}
+
+// Discount is a promotion taken off the order, sent to the gateway as a
+// negative line.
+type Discount struct {
+	Code   string
+	Name   string
+	Amount money.Money
+}
+
+// checkCurrency makes sure every amount on p is in the payment's currency.
+// Lines are stored without a currency and take the payment's when loaded.
+func (p *Payment) checkCurrency() error {
+	c := p.Amount.Currency
+	if c == "" {
+		return fmt.Errorf("payment %s has no currency", p.OrderID)
+	}
+	amounts := []money.Money{p.Tax, p.Fee}
+	for _, item := range p.Items {
+		amounts = append(amounts, item.UnitPrice)
+	}
+	for _, d := range p.Discounts {
+		amounts = append(amounts, d.Amount)
+	}
+	for _, r := range p.Refunds {
+		amounts = append(amounts, r.Amount)
+	}
+	for _, a := range amounts {
+		if a.Currency != c && !(a.Currency == "" && a.IsZero()) {
+			return fmt.Errorf("payment %s: %w: %s and %s", p.OrderID, money.ErrCurrencyMismatch, a.Currency, c)
+		}
+	}
+	return nil
+}
+
+// Repository stores payments keyed by their Midtrans order ID. Items and
+// discounts are written with the payment and are never changed afterwards;
+// refunds are added and updated through Update. Events recorded on the
+// payment are written to the outbox in the same transaction.
+type Repository interface {
+	Create(ctx context.Context, p *Payment) error
+	GetByOrderID(ctx context.Context, orderID string) (*Payment, error)
+	// Update loads the payment, lets fn modify it and saves the result
+	// atomically. If fn returns an error nothing is written.
+	Update(ctx context.Context, orderID string, fn func(p *Payment) error) (*Payment, error)
+	// ClaimStalePending returns up to limit pending payments created before
+	// cutoff and not reconciled since, oldest first, and marks them as
+	// reconciled now so concurrent reconcilers do not check them twice.
+	ClaimStalePending(ctx context.Context, cutoff time.Time, limit int) ([]string, error)
+	// ListExpiredPending returns up to limit pending payments whose expiry
+	// is before cutoff, earliest first.
+	ListExpiredPending(ctx context.Context, cutoff time.Time, limit int) ([]string, error)
+	// ListStalePendingRefunds returns up to limit payments with a refund
+	// still pending that was last updated before cutoff, oldest first.
+	ListStalePendingRefunds(ctx context.Context, cutoff time.Time, limit int) ([]string, error)
+}
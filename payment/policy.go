@@ -0,0 +1,157 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Option groups a client can be allowed to set.
+const (
+	OptionPaymentMethods = "payment_methods"
+	OptionCallbacks      = "callbacks"
+	OptionCustomFields   = "custom_fields"
+	OptionCreditCard     = "credit_card"
+)
+
+var optionNames = []string{OptionPaymentMethods, OptionCallbacks, OptionCustomFields, OptionCreditCard}
+
+// OptionsPolicy says which Options each API client may set. Clients are
+// identified by the token's azp or client_id claim; clients not listed, and
+// tokens without either claim, get Default.
+type OptionsPolicy struct {
+	Default ClientPolicy            `json:"default"`
+	Clients map[string]ClientPolicy `json:"clients"`
+}
+
+type ClientPolicy struct {
+	// Allow lists the option groups the client may set.
+	Allow []string `json:"allow"`
+	// PaymentMethods limits which methods the client may enable; empty
+	// allows any.
+	PaymentMethods []string `json:"payment_methods"`
+	// CallbackHosts are the hosts callback URLs may point at. A leading
+	// "*." matches any subdomain.
+	CallbackHosts []string `json:"callback_hosts"`
+}
+
+// DefaultOptionsPolicy lets every client choose payment methods and
+// nothing else.
+func DefaultOptionsPolicy() *OptionsPolicy {
+	return &OptionsPolicy{Default: ClientPolicy{Allow: []string{OptionPaymentMethods}}}
+}
+
+// LoadOptionsPolicy reads a policy from a JSON file such as
+//
+//	{"default": {"allow": ["payment_methods"]},
+//	 "clients": {"storefront": {"allow": ["payment_methods", "callbacks"],
+//	                            "callback_hosts": ["shop.example.com"]}}}
+func LoadOptionsPolicy(path string) (*OptionsPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read payment options policy: %w", err)
+	}
+	var p OptionsPolicy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse payment options policy %s: %w", path, err)
+	}
+
+	if err := p.Default.validate(); err != nil {
+		return nil, fmt.Errorf("payment options policy %s: default: %w", path, err)
+	}
+	for client, cp := range p.Clients {
+		if err := cp.validate(); err != nil {
+			return nil, fmt.Errorf("payment options policy %s: client %s: %w", path, client, err)
+		}
+	}
+	return &p, nil
+}
+
+func (cp ClientPolicy) validate() error {
+	for _, name := range cp.Allow {
+		if !slices.Contains(optionNames, name) {
+			return fmt.Errorf("unknown option %q (known: %s)", name, strings.Join(optionNames, ", "))
+		}
+	}
+	for _, m := range cp.PaymentMethods {
+		if !IsMethod(m) {
+			return fmt.Errorf("unknown payment method %q", m)
+		}
+	}
+	if slices.Contains(cp.Allow, OptionCallbacks) && len(cp.CallbackHosts) == 0 {
+		return fmt.Errorf("callbacks are allowed but no callback_hosts are listed")
+	}
+	return nil
+}
+
+// For returns the policy that applies to clientID.
+func (p *OptionsPolicy) For(clientID string) ClientPolicy {
+	if cp, ok := p.Clients[clientID]; ok && clientID != "" {
+		return cp
+	}
+	return p.Default
+}
+
+// Check returns an *OptionsError with NotAllowed set when clientID may not
+// use o.
+func (p *OptionsPolicy) Check(clientID string, o *Options) error {
+	cp := p.For(clientID)
+	var problems []string
+	deny := func(name string) {
+		problems = append(problems, fmt.Sprintf("this client may not set %s", name))
+	}
+
+	if len(o.PaymentMethods) > 0 {
+		if !slices.Contains(cp.Allow, OptionPaymentMethods) {
+			deny(OptionPaymentMethods)
+		} else if len(cp.PaymentMethods) > 0 {
+			for _, m := range o.PaymentMethods {
+				if !slices.Contains(cp.PaymentMethods, m) {
+					problems = append(problems, fmt.Sprintf("this client may not enable payment method %q", m))
+				}
+			}
+		}
+	}
+
+	if o.Callbacks != nil {
+		if !slices.Contains(cp.Allow, OptionCallbacks) {
+			deny(OptionCallbacks)
+		} else {
+			for _, raw := range o.Callbacks.URLs() {
+				if u, err := url.Parse(raw); err != nil || !hostAllowed(cp.CallbackHosts, u.Hostname()) {
+					problems = append(problems, fmt.Sprintf("callback URL %q is not on an allowed host", raw))
+				}
+			}
+		}
+	}
+
+	if len(o.CustomFields) > 0 && !slices.Contains(cp.Allow, OptionCustomFields) {
+		deny(OptionCustomFields)
+	}
+	if o.CreditCard != nil && !slices.Contains(cp.Allow, OptionCreditCard) {
+		deny(OptionCreditCard)
+	}
+
+	if len(problems) > 0 {
+		return &OptionsError{Problems: problems, NotAllowed: true}
+	}
+	return nil
+}
+
+func hostAllowed(allowed []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, a := range allowed {
+		a = strings.ToLower(a)
+		if suffix, ok := strings.CutPrefix(a, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == a {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,464 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+
+	"payment-service-iae/money"
+	"payment-service-iae/outbox"
+)
+
+const uniqueViolation = "23505"
+
+// statusChannel is the LISTEN/NOTIFY channel a stored status change is
+// announced on, so every instance can pass it to its watchers.
+const statusChannel = "payment_status"
+
+type statusNotice struct {
+	OrderID  string `json:"order_id"`
+	Status   Status `json:"status"`
+	Previous Status `json:"previous"`
+}
+
+const paymentColumns = `order_id, book_id, customer_id, amount, currency, snap_token, redirect_url, status,
+	transaction_id, payment_type, fraud_status, settled_at, expires_at, options, instructions, subscription_id, tax_rate, tax, fee, created_at, updated_at`
+
+type PostgresRepository struct {
+	db *sql.DB
+}
+
+func NewPostgresRepository(db *sql.DB) *PostgresRepository {
+	return &PostgresRepository{db: db}
+}
+
+func (r *PostgresRepository) Create(ctx context.Context, p *Payment) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin insert payment %s: %w", p.OrderID, err)
+	}
+	defer tx.Rollback()
+
+	options, err := marshalJSON(p.Options)
+	if err != nil {
+		return fmt.Errorf("encode payment %s options: %w", p.OrderID, err)
+	}
+	instructions, err := marshalJSON(p.Instructions)
+	if err != nil {
+		return fmt.Errorf("encode payment %s instructions: %w", p.OrderID, err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO payments (order_id, book_id, customer_id, amount, currency, snap_token, redirect_url, status,
+			transaction_id, payment_type, expires_at, options, instructions, subscription_id, tax_rate, tax, fee)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, NULLIF($14, ''), $15, $16, $17)
+		RETURNING created_at, updated_at`,
+		p.OrderID, p.BookID, p.CustomerID, p.Amount.Amount, p.Amount.Currency, p.SnapToken, p.RedirectURL, p.Status,
+		p.TransactionID, p.PaymentType, p.ExpiresAt, options, instructions, p.SubscriptionID,
+		p.TaxRate, p.Tax.Amount, p.Fee.Amount,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert payment %s: %w", p.OrderID, err)
+	}
+
+	for i, item := range p.Items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO payment_items (order_id, position, book_id, title, unit_price, quantity)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			p.OrderID, i, item.BookID, item.Title, item.UnitPrice.Amount, item.Quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("insert payment %s item %d: %w", p.OrderID, i, err)
+		}
+	}
+	for i, d := range p.Discounts {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO payment_discounts (order_id, position, code, name, amount)
+			VALUES ($1, $2, $3, $4, $5)`,
+			p.OrderID, i, d.Code, d.Name, d.Amount.Amount,
+		)
+		if err != nil {
+			return fmt.Errorf("insert payment %s discount %d: %w", p.OrderID, i, err)
+		}
+	}
+
+	if err := outbox.Write(ctx, tx, p.takeEvents()...); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit payment %s: %w", p.OrderID, err)
+	}
+	return nil
+}
+
+func (r *PostgresRepository) GetByOrderID(ctx context.Context, orderID string) (*Payment, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+paymentColumns+` FROM payments WHERE order_id = $1`, orderID)
+	p, err := scanPayment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select payment %s: %w", orderID, err)
+	}
+
+	if p.Items, err = loadItems(ctx, r.db, orderID, p.Amount.Currency); err != nil {
+		return nil, err
+	}
+	if p.Discounts, err = loadDiscounts(ctx, r.db, orderID, p.Amount.Currency); err != nil {
+		return nil, err
+	}
+	if p.Refunds, err = loadRefunds(ctx, r.db, orderID, p.Amount.Currency); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (r *PostgresRepository) Update(ctx context.Context, orderID string, fn func(p *Payment) error) (*Payment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin update payment %s: %w", orderID, err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `SELECT `+paymentColumns+` FROM payments WHERE order_id = $1 FOR UPDATE`, orderID)
+	p, err := scanPayment(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select payment %s: %w", orderID, err)
+	}
+
+	if p.Items, err = loadItems(ctx, tx, orderID, p.Amount.Currency); err != nil {
+		return nil, err
+	}
+	if p.Discounts, err = loadDiscounts(ctx, tx, orderID, p.Amount.Currency); err != nil {
+		return nil, err
+	}
+	if p.Refunds, err = loadRefunds(ctx, tx, orderID, p.Amount.Currency); err != nil {
+		return nil, err
+	}
+
+	previous := p.Status
+	if err := fn(p); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE payments
+		SET snap_token = $2, redirect_url = $3, status = $4, transaction_id = $5,
+			payment_type = $6, fraud_status = $7, settled_at = $8, updated_at = NOW()
+		WHERE order_id = $1
+		RETURNING updated_at`,
+		p.OrderID, p.SnapToken, p.RedirectURL, p.Status, p.TransactionID,
+		p.PaymentType, p.FraudStatus, p.SettledAt,
+	).Scan(&p.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("update payment %s: %w", orderID, err)
+	}
+
+	for i := range p.Refunds {
+		if err := saveRefund(ctx, tx, &p.Refunds[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := outbox.Write(ctx, tx, p.takeEvents()...); err != nil {
+		return nil, err
+	}
+
+	if p.Status != previous {
+		// Delivered to listeners only once the transaction commits.
+		notice, err := json.Marshal(statusNotice{OrderID: p.OrderID, Status: p.Status, Previous: previous})
+		if err != nil {
+			return nil, fmt.Errorf("encode status notice for %s: %w", orderID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `SELECT pg_notify($1, $2)`, statusChannel, string(notice)); err != nil {
+			return nil, fmt.Errorf("notify status of %s: %w", orderID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit payment %s: %w", orderID, err)
+	}
+	return p, nil
+}
+
+// ListenStatus calls changed for every status change any instance stores,
+// until ctx is done or the connection fails.
+func (r *PostgresRepository) ListenStatus(ctx context.Context, changed func(orderID string, status, previous Status)) error {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("listen for status changes: %w", err)
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(dc any) error {
+		pc := dc.(*stdlib.Conn).Conn()
+		if _, err := pc.Exec(ctx, "LISTEN "+statusChannel); err != nil {
+			return fmt.Errorf("listen for status changes: %w", err)
+		}
+		for {
+			n, err := pc.WaitForNotification(ctx)
+			if err != nil {
+				// The connection is still listening; keep it out of the pool.
+				return fmt.Errorf("wait for status changes: %w: %w", err, driver.ErrBadConn)
+			}
+			var notice statusNotice
+			if err := json.Unmarshal([]byte(n.Payload), &notice); err != nil {
+				log.Printf("Ignored malformed status notice %q: %v", n.Payload, err)
+				continue
+			}
+			changed(notice.OrderID, notice.Status, notice.Previous)
+		}
+	})
+}
+
+func (r *PostgresRepository) ClaimStalePending(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		WITH due AS (
+			SELECT order_id FROM payments
+			WHERE status = $1 AND COALESCE(reconciled_at, created_at) < $2
+			ORDER BY COALESCE(reconciled_at, created_at)
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE payments p SET reconciled_at = NOW()
+		FROM due WHERE p.order_id = due.order_id
+		RETURNING p.order_id`,
+		StatusPending, cutoff, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim stale pending payments: %w", err)
+	}
+	defer rows.Close()
+
+	var orderIDs []string
+	for rows.Next() {
+		var orderID string
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, fmt.Errorf("scan stale pending payment: %w", err)
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	return orderIDs, rows.Err()
+}
+
+func (r *PostgresRepository) ListExpiredPending(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT order_id FROM payments
+		WHERE status = $1 AND expires_at < $2
+		ORDER BY expires_at
+		LIMIT $3`,
+		StatusPending, cutoff, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select expired payments: %w", err)
+	}
+	defer rows.Close()
+
+	var orderIDs []string
+	for rows.Next() {
+		var orderID string
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, fmt.Errorf("scan expired payment: %w", err)
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	return orderIDs, rows.Err()
+}
+
+func (r *PostgresRepository) ListStalePendingRefunds(ctx context.Context, cutoff time.Time, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT order_id FROM refunds
+		WHERE status = $1 AND updated_at < $2
+		GROUP BY order_id
+		ORDER BY MIN(updated_at)
+		LIMIT $3`,
+		RefundPending, cutoff, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select stale pending refunds: %w", err)
+	}
+	defer rows.Close()
+
+	var orderIDs []string
+	for rows.Next() {
+		var orderID string
+		if err := rows.Scan(&orderID); err != nil {
+			return nil, fmt.Errorf("scan stale pending refund: %w", err)
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	return orderIDs, rows.Err()
+}
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func loadItems(ctx context.Context, q querier, orderID string, currency money.Currency) ([]Item, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT book_id, title, unit_price, quantity
+		FROM payment_items
+		WHERE order_id = $1
+		ORDER BY position`,
+		orderID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select payment %s items: %w", orderID, err)
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var item Item
+		var unitPrice int64
+		if err := rows.Scan(&item.BookID, &item.Title, &unitPrice, &item.Quantity); err != nil {
+			return nil, fmt.Errorf("scan payment %s item: %w", orderID, err)
+		}
+		item.UnitPrice = money.New(unitPrice, currency)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select payment %s items: %w", orderID, err)
+	}
+	return items, nil
+}
+
+func loadDiscounts(ctx context.Context, q querier, orderID string, currency money.Currency) ([]Discount, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT code, name, amount
+		FROM payment_discounts
+		WHERE order_id = $1
+		ORDER BY position`,
+		orderID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select payment %s discounts: %w", orderID, err)
+	}
+	defer rows.Close()
+
+	var discounts []Discount
+	for rows.Next() {
+		var d Discount
+		var amount int64
+		if err := rows.Scan(&d.Code, &d.Name, &amount); err != nil {
+			return nil, fmt.Errorf("scan payment %s discount: %w", orderID, err)
+		}
+		d.Amount = money.New(amount, currency)
+		discounts = append(discounts, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select payment %s discounts: %w", orderID, err)
+	}
+	return discounts, nil
+}
+
+func loadRefunds(ctx context.Context, q querier, orderID string, currency money.Currency) ([]Refund, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT refund_key, order_id, amount, reason, status, gateway_refund_id, created_at, updated_at
+		FROM refunds
+		WHERE order_id = $1
+		ORDER BY created_at`,
+		orderID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select payment %s refunds: %w", orderID, err)
+	}
+	defer rows.Close()
+
+	var refunds []Refund
+	for rows.Next() {
+		var r Refund
+		var amount int64
+		if err := rows.Scan(&r.RefundKey, &r.OrderID, &amount, &r.Reason, &r.Status, &r.GatewayRefundID, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan payment %s refund: %w", orderID, err)
+		}
+		r.Amount = money.New(amount, currency)
+		refunds = append(refunds, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select payment %s refunds: %w", orderID, err)
+	}
+	return refunds, nil
+}
+
+func saveRefund(ctx context.Context, tx *sql.Tx, r *Refund) error {
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO refunds (refund_key, order_id, amount, reason, status, gateway_refund_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (refund_key) DO UPDATE
+			SET status = EXCLUDED.status, gateway_refund_id = EXCLUDED.gateway_refund_id, updated_at = NOW()
+			WHERE refunds.status <> EXCLUDED.status OR refunds.gateway_refund_id <> EXCLUDED.gateway_refund_id
+		RETURNING created_at, updated_at`,
+		r.RefundKey, r.OrderID, r.Amount.Amount, r.Reason, r.Status, r.GatewayRefundID,
+	).Scan(&r.CreatedAt, &r.UpdatedAt)
+	// No row comes back when the refund exists and nothing changed.
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("save refund %s: %w", r.RefundKey, err)
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row rowScanner) (*Payment, error) {
+	p := &Payment{}
+	var options, instructions []byte
+	var subscriptionID sql.NullString
+	var amount, tax, fee int64
+	var currency money.Currency
+	err := row.Scan(
+		&p.OrderID, &p.BookID, &p.CustomerID, &amount, &currency, &p.SnapToken, &p.RedirectURL, &p.Status,
+		&p.TransactionID, &p.PaymentType, &p.FraudStatus, &p.SettledAt, &p.ExpiresAt, &options, &instructions,
+		&subscriptionID, &p.TaxRate, &tax, &fee, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.Amount = money.New(amount, currency)
+	p.Tax = money.New(tax, currency)
+	p.Fee = money.New(fee, currency)
+	p.SubscriptionID = subscriptionID.String
+	if options != nil {
+		p.Options = &Options{}
+		if err := json.Unmarshal(options, p.Options); err != nil {
+			return nil, fmt.Errorf("decode payment %s options: %w", p.OrderID, err)
+		}
+	}
+	if instructions != nil {
+		p.Instructions = &Instructions{}
+		if err := json.Unmarshal(instructions, p.Instructions); err != nil {
+			return nil, fmt.Errorf("decode payment %s instructions: %w", p.OrderID, err)
+		}
+	}
+	return p, nil
+}
+
+// marshalJSON encodes v for a nullable JSONB column; nil stays NULL.
+func marshalJSON[T any](v *T) ([]byte, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
@@ -0,0 +1,177 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"payment-service-iae/money"
+)
+
+// Rate is a percentage in basis points: 1100 is 11%.
+type Rate int64
+
+const maxRate Rate = 10000
+
+var (
+	errRate = errors.New("must be a percentage with at most two decimals, such as 11 or 0.7%")
+	errFee  = errors.New("must be a rupiah amount, a percentage or both, such as 4000, 0.7% or 2000+2.9%")
+)
+
+// ParseRate reads a percentage with up to two decimals, such as "11",
+// "11%" or "0.7%".
+func ParseRate(s string) (Rate, error) {
+	value := strings.TrimSuffix(strings.TrimSpace(s), "%")
+	whole, frac, _ := strings.Cut(value, ".")
+	if whole == "" || len(frac) > 2 {
+		return 0, errRate
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	w, err := strconv.ParseUint(whole, 10, 16)
+	if err != nil {
+		return 0, errRate
+	}
+	f, err := strconv.ParseUint(frac, 10, 8)
+	if err != nil {
+		return 0, errRate
+	}
+	r := Rate(w*100 + f)
+	if r > maxRate {
+		return 0, errors.New("must not be over 100%")
+	}
+	return r, nil
+}
+
+func (r Rate) String() string {
+	s := strconv.FormatInt(int64(r/100), 10)
+	if frac := r % 100; frac != 0 {
+		s += strings.TrimRight(fmt.Sprintf(".%02d", frac), "0")
+	}
+	return s + "%"
+}
+
+// Of is r percent of m in whole minor units, rounded down or, with up,
+// rounded up.
+func (r Rate) Of(m money.Money, up bool) money.Money {
+	// Split so amount*r cannot overflow.
+	part := m.Amount / 10000 * int64(r)
+	rest := m.Amount % 10000 * int64(r)
+	part += rest / 10000
+	if up && rest%10000 > 0 {
+		part++
+	}
+	return money.New(part, m.Currency)
+}
+
+// Fee is a payment method's convenience fee: a fixed amount plus a rate
+// of what the customer pays before the fee.
+type Fee struct {
+	Fixed money.Money
+	Rate  Rate
+}
+
+// ParseFee reads a fee written as a fixed rupiah amount, a percentage or
+// both joined by "+", e.g. "4000", "0.7%" or "2000+2.9%". Fees are
+// configured in rupiah, the only currency Midtrans charges.
+func ParseFee(s string) (Fee, error) {
+	var fee Fee
+	for _, term := range strings.Split(s, "+") {
+		term = strings.TrimSpace(term)
+		if strings.HasSuffix(term, "%") {
+			r, err := ParseRate(term)
+			if err != nil {
+				return Fee{}, errFee
+			}
+			fee.Rate += r
+			continue
+		}
+		n, err := strconv.ParseInt(term, 10, 64)
+		if err != nil || n < 0 {
+			return Fee{}, errFee
+		}
+		if fee.Fixed, err = fee.Fixed.Add(money.Rupiah(n)); err != nil {
+			return Fee{}, errFee
+		}
+	}
+	return fee, nil
+}
+
+// PricingPolicy adds PPN and convenience fees to an order.
+type PricingPolicy struct {
+	TaxRate Rate
+	// Fees are per payment method; methods not listed have none.
+	Fees map[string]Fee
+}
+
+// Breakdown is an order's itemized price.
+type Breakdown struct {
+	Subtotal money.Money
+	Discount money.Money
+	TaxRate  Rate
+	Tax      money.Money
+	Fee      money.Money
+	Total    money.Money
+}
+
+// FeeFor returns the fee for a payment that may be paid with any of
+// methods, or with any method when none are given. A fee is only charged
+// when every method carries the same one, since the customer may still
+// pick any of them at the gateway.
+func (p PricingPolicy) FeeFor(methods ...string) Fee {
+	if len(methods) == 0 {
+		methods = Methods
+	}
+	fee := p.Fees[methods[0]]
+	for _, m := range methods[1:] {
+		if other := p.Fees[m]; other.Rate != fee.Rate || other.Fixed.Amount != fee.Fixed.Amount {
+			return Fee{}
+		}
+	}
+	return fee
+}
+
+// Price adds tax on the discounted subtotal and then the fee for methods.
+// Rupiah have no smaller unit: PPN is rounded down to whole rupiah, as on
+// a tax invoice, and percentage fees are rounded up so they always cover
+// the provider's charge. It fails when the amounts are in different
+// currencies or the total overflows.
+func (p PricingPolicy) Price(subtotal, discount money.Money, methods ...string) (Breakdown, error) {
+	b := Breakdown{Subtotal: subtotal, Discount: discount, TaxRate: p.TaxRate}
+	taxable, err := subtotal.Sub(discount)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	b.Tax = p.TaxRate.Of(taxable, false)
+	taxed, err := taxable.Add(b.Tax)
+	if err != nil {
+		return Breakdown{}, err
+	}
+	fee := p.FeeFor(methods...)
+	if b.Fee, err = fee.Rate.Of(taxed, true).Add(fee.Fixed); err != nil {
+		return Breakdown{}, err
+	}
+	if b.Total, err = taxed.Add(b.Fee); err != nil {
+		return Breakdown{}, err
+	}
+	return b, nil
+}
+
+// Breakdown itemizes the payment's amount for invoicing.
+func (p *Payment) Breakdown() Breakdown {
+	// The amounts were checked when the payment was priced.
+	var discount int64
+	for _, d := range p.Discounts {
+		discount += d.Amount.Amount
+	}
+	c := p.Amount.Currency
+	return Breakdown{
+		// Derived rather than summed from Items, which renewals do not have.
+		Subtotal: money.New(p.Amount.Amount-p.Fee.Amount-p.Tax.Amount+discount, c),
+		Discount: money.New(discount, c),
+		TaxRate:  p.TaxRate,
+		Tax:      money.New(p.Tax.Amount, c),
+		Fee:      money.New(p.Fee.Amount, c),
+		Total:    p.Amount,
+	}
+}
@@ -0,0 +1,213 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-service-iae/money"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotRefundable    = errors.New("payment cannot be refunded in its current status")
+	ErrRefundTooLarge   = errors.New("refund exceeds the amount left to refund")
+	ErrRefundNotPending = errors.New("refund is not pending")
+)
+
+type RefundStatus string
+
+const (
+	// RefundPending holds back the amount while the gateway call is running,
+	// and afterwards when the gateway gave no definite answer.
+	RefundPending   RefundStatus = "pending"
+	RefundSucceeded RefundStatus = "succeeded"
+	RefundFailed    RefundStatus = "failed"
+)
+
+type Refund struct {
+	RefundKey       string
+	OrderID         string
+	Amount          money.Money
+	Reason          string
+	Status          RefundStatus
+	GatewayRefundID string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// Refundable reports whether a payment in status s can be refunded.
+func (s Status) Refundable() bool {
+	return s == StatusSettlement || s == StatusPartialRefund
+}
+
+// RefundedAmount is the total of refunds that went through.
+func (p *Payment) RefundedAmount() money.Money {
+	return p.refundTotal(func(r Refund) bool { return r.Status == RefundSucceeded })
+}
+
+// reservedRefundAmount also counts refunds still in flight, so concurrent
+// refunds cannot together exceed the paid amount.
+func (p *Payment) reservedRefundAmount() money.Money {
+	return p.refundTotal(func(r Refund) bool { return r.Status != RefundFailed })
+}
+
+// refundTotal adds up the refunds counted. Reserved refunds never exceed
+// the amount paid, so the total cannot overflow.
+func (p *Payment) refundTotal(counted func(Refund) bool) money.Money {
+	var total int64
+	for _, r := range p.Refunds {
+		if counted(r) {
+			total += r.Amount.Amount
+		}
+	}
+	return money.New(total, p.Amount.Currency)
+}
+
+// RefundableAmount is what is still available to refund.
+func (p *Payment) RefundableAmount() money.Money {
+	return money.New(p.Amount.Amount-p.reservedRefundAmount().Amount, p.Amount.Currency)
+}
+
+// ReserveRefund records a pending refund of amount (or everything still
+// refundable when amount is zero) before the gateway is called. The payment
+// row is locked while the remaining amount is checked.
+func (s *Service) ReserveRefund(ctx context.Context, orderID string, amount money.Money, reason string) (*Refund, error) {
+	var refund Refund
+	_, err := s.repo.Update(ctx, orderID, func(p *Payment) error {
+		if !p.Status.Refundable() {
+			return fmt.Errorf("%w: %s", ErrNotRefundable, p.Status)
+		}
+
+		remaining := p.RefundableAmount()
+		if amount.IsZero() {
+			amount = remaining
+		}
+		over, err := amount.Cmp(remaining)
+		if err != nil {
+			return err
+		}
+		if !amount.IsPositive() || over > 0 {
+			return fmt.Errorf("%w: requested %s, remaining %s", ErrRefundTooLarge, amount, remaining)
+		}
+
+		refund = Refund{
+			RefundKey: "RF-" + uuid.New().String(),
+			OrderID:   p.OrderID,
+			Amount:    amount,
+			Reason:    reason,
+			Status:    RefundPending,
+		}
+		p.Refunds = append(p.Refunds, refund)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// PendingRefund returns a reserved refund whose outcome at the gateway is
+// not known yet, so it can be sent again with the same key.
+func (s *Service) PendingRefund(ctx context.Context, orderID, refundKey string) (*Refund, error) {
+	p, err := s.repo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	r := p.findRefund(refundKey)
+	if r == nil || r.Status != RefundPending {
+		return nil, fmt.Errorf("%w: %s", ErrRefundNotPending, refundKey)
+	}
+	refund := *r
+	return &refund, nil
+}
+
+// CompleteRefund marks a reserved refund as done and moves the payment to
+// refund or partial_refund depending on how much has now been returned. A
+// refund the gateway's status already reported as done is left as it is,
+// and the event is only recorded when the payment's status changes.
+func (s *Service) CompleteRefund(ctx context.Context, orderID, refundKey, gatewayRefundID string) (*Payment, error) {
+	var previous Status
+	var changed bool
+	p, err := s.repo.Update(ctx, orderID, func(p *Payment) error {
+		previous = p.Status
+		r := p.findRefund(refundKey)
+		if r == nil {
+			return fmt.Errorf("refund %s not found on payment %s", refundKey, orderID)
+		}
+		if r.Status == RefundSucceeded {
+			return nil
+		}
+		r.Status = RefundSucceeded
+		r.GatewayRefundID = gatewayRefundID
+
+		var err error
+		if changed, err = transition(p, p.afterRefund(StatusPartialRefund)); err != nil {
+			return err
+		}
+		if changed {
+			return p.recordEvent(statusEvents[p.Status], previous, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		s.publishStatus(StatusChange{Payment: p, Previous: previous})
+	}
+	return p, nil
+}
+
+// FailRefund releases the amount held by a pending refund the gateway
+// rejected. It must not be used when the gateway may have refunded after
+// all.
+func (s *Service) FailRefund(ctx context.Context, orderID, refundKey string) error {
+	_, err := s.repo.Update(ctx, orderID, func(p *Payment) error {
+		r := p.findRefund(refundKey)
+		if r == nil {
+			return fmt.Errorf("refund %s not found on payment %s", refundKey, orderID)
+		}
+		if r.Status != RefundPending {
+			return fmt.Errorf("%w: %s", ErrRefundNotPending, refundKey)
+		}
+		r.Status = RefundFailed
+		return nil
+	})
+	return err
+}
+
+// afterRefund is the status once refunds are done: refund when everything
+// has been returned, else next, which is what the gateway reports. A late
+// partial_refund never undoes refund.
+func (p *Payment) afterRefund(next Status) Status {
+	if p.Status == StatusRefund || p.RefundedAmount().Amount >= p.Amount.Amount {
+		return StatusRefund
+	}
+	return next
+}
+
+// completeRefunds marks the pending refunds the gateway made as done and
+// returns the last of them, or nil.
+func (p *Payment) completeRefunds(made []MadeRefund) *Refund {
+	var last *Refund
+	for _, m := range made {
+		if r := p.findRefund(m.RefundKey); r != nil && r.Status == RefundPending {
+			r.Status = RefundSucceeded
+			r.GatewayRefundID = m.RefundID
+			last = r
+		}
+	}
+	return last
+}
+
+func (p *Payment) findRefund(refundKey string) *Refund {
+	for i := range p.Refunds {
+		if p.Refunds[i].RefundKey == refundKey {
+			return &p.Refunds[i]
+		}
+	}
+	return nil
+}
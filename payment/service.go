@@ -0,0 +1,176 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"payment-service-iae/money"
+)
+
+var ErrAmountMismatch = errors.New("gross amount does not match stored payment")
+
+// StatusUpdate is a gateway-reported change to a payment, either from a
+// notification or from polling the transaction status.
+type StatusUpdate struct {
+	Status Status
+	// GrossAmount is what the gateway says was charged; zero when unknown.
+	GrossAmount   money.Money
+	TransactionID string
+	PaymentType   string
+	FraudStatus   string
+	SettledAt     *time.Time
+	// SavedCard is set when the customer asked to save the card they paid
+	// with.
+	SavedCard *SavedCard
+	// Refunds are the refunds the gateway reports as made; pending ones
+	// among them are completed with the update.
+	Refunds []MadeRefund
+}
+
+// MadeRefund is a refund the gateway has made.
+type MadeRefund struct {
+	RefundKey string
+	RefundID  string
+}
+
+// SavedCard is a reusable card token the gateway issued with a payment.
+type SavedCard struct {
+	Token      string
+	ExpiresAt  *time.Time
+	MaskedCard string
+	CardType   string
+	Bank       string
+}
+
+// PaidHook acts on a payment an update left paid, e.g. by saving its card.
+// It runs for every such update, repeats included, so it must be
+// idempotent.
+type PaidHook func(ctx context.Context, p *Payment, u StatusUpdate) error
+
+// Service is the single place payment status changes go through, so every
+// source of updates obeys the same state machine and has the same effects.
+type Service struct {
+	repo      Repository
+	feed      *statusFeed
+	listener  StatusListener
+	paidHooks []PaidHook
+}
+
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, feed: newStatusFeed()}
+}
+
+// OnPaid adds hooks that ApplyStatusUpdate runs once an update leaves a
+// payment paid. Register them before the service is used.
+func (s *Service) OnPaid(hooks ...PaidHook) {
+	s.paidHooks = append(s.paidHooks, hooks...)
+}
+
+func (s *Service) Create(ctx context.Context, p *Payment) error {
+	if err := p.checkCurrency(); err != nil {
+		return err
+	}
+	if err := p.recordEvent(EventCreated, "", nil); err != nil {
+		return err
+	}
+	if p.Status != StatusPending {
+		if err := p.recordStatusEvent(""); err != nil {
+			return err
+		}
+	}
+	return s.repo.Create(ctx, p)
+}
+
+func (s *Service) Get(ctx context.Context, orderID string) (*Payment, error) {
+	return s.repo.GetByOrderID(ctx, orderID)
+}
+
+// ClaimStalePending hands out pending payments that have gone without an
+// update for longer than age; see Repository.ClaimStalePending.
+func (s *Service) ClaimStalePending(ctx context.Context, age time.Duration, limit int) ([]string, error) {
+	return s.repo.ClaimStalePending(ctx, time.Now().Add(-age), limit)
+}
+
+// StalePendingRefunds returns payments with a refund that has been pending
+// for more than age, whose outcome the gateway has to settle.
+func (s *Service) StalePendingRefunds(ctx context.Context, age time.Duration, limit int) ([]string, error) {
+	return s.repo.ListStalePendingRefunds(ctx, time.Now().Add(-age), limit)
+}
+
+// ExpiredPending returns pending payments whose expiry passed more than
+// grace ago.
+func (s *Service) ExpiredPending(ctx context.Context, grace time.Duration, limit int) ([]string, error) {
+	return s.repo.ListExpiredPending(ctx, time.Now().Add(-grace), limit)
+}
+
+// ApplyStatusUpdate moves the payment to u.Status, or keeps it pending while
+// the capture is held for fraud review; see ResolveStatus. Repeating the
+// current status is a no-op and reports changed=false; a transition the
+// state machine does not allow returns ErrInvalidTransition. If the payment
+// ends up paid, the OnPaid hooks run, and their error is returned so the
+// source of the update tries it again.
+func (s *Service) ApplyStatusUpdate(ctx context.Context, orderID string, u StatusUpdate) (p *Payment, changed bool, err error) {
+	var previous Status
+	p, err = s.repo.Update(ctx, orderID, func(p *Payment) error {
+		previous = p.Status
+		if !u.GrossAmount.IsZero() && u.GrossAmount != p.Amount {
+			return fmt.Errorf("%w: got %s, stored %s", ErrAmountMismatch, u.GrossAmount, p.Amount)
+		}
+		refund := p.completeRefunds(u.Refunds)
+		next := ResolveStatus(u.Status, u.FraudStatus)
+		if next == StatusRefund || next == StatusPartialRefund {
+			next = p.afterRefund(next)
+		}
+		var err error
+		if changed, err = transition(p, next); err != nil {
+			return err
+		}
+		if u.TransactionID != "" {
+			p.TransactionID = u.TransactionID
+		}
+		if u.PaymentType != "" {
+			p.PaymentType = u.PaymentType
+		}
+		if u.FraudStatus != "" {
+			p.FraudStatus = u.FraudStatus
+		}
+		if u.SettledAt != nil {
+			p.SettledAt = u.SettledAt
+		}
+		if changed && refund != nil {
+			return p.recordEvent(statusEvents[p.Status], previous, refund)
+		}
+		if changed {
+			return p.recordStatusEvent(previous)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	if changed {
+		s.publishStatus(StatusChange{Payment: p, Previous: previous})
+	}
+	if p.Status.IsPaid() {
+		for _, hook := range s.paidHooks {
+			if err := hook(ctx, p, u); err != nil {
+				return nil, false, fmt.Errorf("after %s was paid: %w", orderID, err)
+			}
+		}
+	}
+	return p, changed, nil
+}
+
+// transition sets p.Status to next if the state machine allows it.
+func transition(p *Payment, next Status) (changed bool, err error) {
+	if p.Status == next {
+		return false, nil
+	}
+	if !p.Status.CanTransitionTo(next) {
+		return false, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, p.Status, next)
+	}
+	p.Status = next
+	return true, nil
+}
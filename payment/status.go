@@ -0,0 +1,70 @@
+package payment
+
+import "errors"
+
+var ErrInvalidTransition = errors.New("invalid payment status transition")
+
+// Status mirrors gateway.Status: the Midtrans transaction_status values,
+// plus failed for transactions the gateway never accepted.
+type Status string
+
+const (
+	StatusPending       Status = "pending"
+	StatusCapture       Status = "capture"
+	StatusSettlement    Status = "settlement"
+	StatusDeny          Status = "deny"
+	StatusCancel        Status = "cancel"
+	StatusExpire        Status = "expire"
+	StatusRefund        Status = "refund"
+	StatusPartialRefund Status = "partial_refund"
+	StatusFailed        Status = "failed"
+)
+
+// Fraud statuses the gateway reports for card charges. A challenged charge
+// is held until the merchant accepts or denies it.
+const (
+	FraudAccept    = "accept"
+	FraudChallenge = "challenge"
+	FraudDeny      = "deny"
+)
+
+var transitions = map[Status][]Status{
+	StatusPending:       {StatusCapture, StatusSettlement, StatusDeny, StatusCancel, StatusExpire, StatusFailed},
+	StatusCapture:       {StatusSettlement, StatusDeny, StatusCancel, StatusRefund, StatusPartialRefund},
+	StatusSettlement:    {StatusRefund, StatusPartialRefund},
+	StatusPartialRefund: {StatusRefund, StatusPartialRefund},
+	// Snap lets the customer pick another method after a denied card.
+	StatusDeny: {StatusPending, StatusCapture, StatusSettlement, StatusCancel, StatusExpire},
+}
+
+// CanTransitionTo reports whether a payment in status s may move to next.
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveStatus is the status a gateway report of status with fraudStatus
+// moves a payment to. A capture fraud detection has not accepted has not
+// taken the customer's money, so the payment stays pending until a later
+// report accepts or denies it.
+func ResolveStatus(status Status, fraudStatus string) Status {
+	if status == StatusCapture && fraudStatus != FraudAccept {
+		return StatusPending
+	}
+	return status
+}
+
+// IsPaid reports whether the customer's money has been taken.
+func (s Status) IsPaid() bool {
+	return s == StatusSettlement || s == StatusCapture
+}
+
+// Cancellable reports whether a payment in status s can still be cancelled
+// at the gateway, i.e. the money has not settled yet.
+func (s Status) Cancellable() bool {
+	return s == StatusPending || s == StatusCapture
+}
@@ -0,0 +1,163 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"payment-service-iae/money"
+)
+
+var allStatuses = []Status{
+	StatusPending, StatusCapture, StatusSettlement, StatusDeny, StatusCancel,
+	StatusExpire, StatusRefund, StatusPartialRefund, StatusFailed,
+}
+
+func TestCanTransitionTo(t *testing.T) {
+	allowed := map[[2]Status]bool{
+		{StatusPending, StatusCapture}:             true,
+		{StatusPending, StatusSettlement}:          true,
+		{StatusPending, StatusDeny}:                true,
+		{StatusPending, StatusCancel}:              true,
+		{StatusPending, StatusExpire}:              true,
+		{StatusPending, StatusFailed}:              true,
+		{StatusCapture, StatusSettlement}:          true,
+		{StatusCapture, StatusDeny}:                true,
+		{StatusCapture, StatusCancel}:              true,
+		{StatusCapture, StatusRefund}:              true,
+		{StatusCapture, StatusPartialRefund}:       true,
+		{StatusSettlement, StatusRefund}:           true,
+		{StatusSettlement, StatusPartialRefund}:    true,
+		{StatusPartialRefund, StatusRefund}:        true,
+		{StatusPartialRefund, StatusPartialRefund}: true,
+		{StatusDeny, StatusPending}:                true,
+		{StatusDeny, StatusCapture}:                true,
+		{StatusDeny, StatusSettlement}:             true,
+		{StatusDeny, StatusCancel}:                 true,
+		{StatusDeny, StatusExpire}:                 true,
+	}
+	for _, from := range allStatuses {
+		for _, to := range allStatuses {
+			want := allowed[[2]Status{from, to}]
+			if got := from.CanTransitionTo(to); got != want {
+				t.Errorf("%s -> %s: got %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestResolveStatus(t *testing.T) {
+	tests := []struct {
+		status      Status
+		fraudStatus string
+		want        Status
+	}{
+		{StatusCapture, FraudAccept, StatusCapture},
+		{StatusCapture, FraudChallenge, StatusPending},
+		{StatusCapture, FraudDeny, StatusPending},
+		{StatusCapture, "", StatusPending},
+		{StatusSettlement, "", StatusSettlement},
+		{StatusDeny, FraudDeny, StatusDeny},
+		{StatusPending, FraudChallenge, StatusPending},
+	}
+	for _, tt := range tests {
+		if got := ResolveStatus(tt.status, tt.fraudStatus); got != tt.want {
+			t.Errorf("ResolveStatus(%s, %q) = %s, want %s", tt.status, tt.fraudStatus, got, tt.want)
+		}
+	}
+}
+
+func TestStatusPredicates(t *testing.T) {
+	paid := map[Status]bool{StatusCapture: true, StatusSettlement: true}
+	cancellable := map[Status]bool{StatusPending: true, StatusCapture: true}
+	for _, s := range allStatuses {
+		if got := s.IsPaid(); got != paid[s] {
+			t.Errorf("%s.IsPaid() = %v, want %v", s, got, paid[s])
+		}
+		if got := s.Cancellable(); got != cancellable[s] {
+			t.Errorf("%s.Cancellable() = %v, want %v", s, got, cancellable[s])
+		}
+	}
+}
+
+func TestApplyStatusUpdate(t *testing.T) {
+	tests := []struct {
+		name        string
+		updates     []StatusUpdate
+		want        Status
+		wantChanged bool
+		wantErr     error
+		wantEvents  []string
+	}{
+		{
+			name:        "settles",
+			updates:     []StatusUpdate{{Status: StatusSettlement}},
+			want:        StatusSettlement,
+			wantChanged: true,
+			wantEvents:  []string{EventCreated, EventSettled},
+		},
+		{
+			name:       "repeated status is a no-op",
+			updates:    []StatusUpdate{{Status: StatusSettlement}, {Status: StatusSettlement}},
+			want:       StatusSettlement,
+			wantEvents: []string{EventCreated, EventSettled},
+		},
+		{
+			name:       "challenged capture stays pending",
+			updates:    []StatusUpdate{{Status: StatusCapture, FraudStatus: FraudChallenge}},
+			want:       StatusPending,
+			wantEvents: []string{EventCreated},
+		},
+		{
+			name:       "settled payment cannot be cancelled",
+			updates:    []StatusUpdate{{Status: StatusSettlement}, {Status: StatusCancel}},
+			want:       StatusSettlement,
+			wantErr:    ErrInvalidTransition,
+			wantEvents: []string{EventCreated, EventSettled},
+		},
+		{
+			name:       "charged amount must match",
+			updates:    []StatusUpdate{{Status: StatusSettlement, GrossAmount: money.Rupiah(1)}},
+			want:       StatusPending,
+			wantErr:    ErrAmountMismatch,
+			wantEvents: []string{EventCreated},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := NewMemoryRepository()
+			svc := NewService(repo)
+			err := svc.Create(ctx, &Payment{OrderID: "order-1", Amount: money.Rupiah(150000), Status: StatusPending})
+			if err != nil {
+				t.Fatalf("create: %v", err)
+			}
+
+			var changed bool
+			for _, u := range tt.updates {
+				_, changed, err = svc.ApplyStatusUpdate(ctx, "order-1", u)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			p, err := svc.Get(ctx, "order-1")
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if p.Status != tt.want {
+				t.Errorf("status = %s, want %s", p.Status, tt.want)
+			}
+			var events []string
+			for _, e := range repo.Outbox().Pending() {
+				events = append(events, e.Type)
+			}
+			if !slices.Equal(events, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", events, tt.wantEvents)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package promo
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MemoryStore is an in-process Store for tests and local development.
+type MemoryStore struct {
+	mu          sync.Mutex
+	usage       map[string]int
+	customers   map[[2]string]int
+	redemptions map[string][]redemption
+}
+
+type redemption struct {
+	code       string
+	customerID string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		usage:       make(map[string]int),
+		customers:   make(map[[2]string]int),
+		redemptions: make(map[string][]redemption),
+	}
+}
+
+func (s *MemoryStore) Usage(ctx context.Context, code, customerID string) (Usage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Usage{Redemptions: s.usage[code], CustomerRedemptions: s.customers[[2]string{code, customerID}]}, nil
+}
+
+func (s *MemoryStore) Redeem(ctx context.Context, orderID, customerID string, discounts []Applied) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.redemptions[orderID]) > 0 {
+		return fmt.Errorf("promos for %s are already redeemed", orderID)
+	}
+	for _, d := range discounts {
+		p := d.Promo
+		if p.MaxRedemptions > 0 && s.usage[p.Code] >= p.MaxRedemptions {
+			return fmt.Errorf("%w: %s", ErrExhausted, p.Code)
+		}
+		if p.MaxPerCustomer > 0 && s.customers[[2]string{p.Code, customerID}] >= p.MaxPerCustomer {
+			return fmt.Errorf("%w: %s for this customer", ErrExhausted, p.Code)
+		}
+	}
+	for _, d := range discounts {
+		s.usage[d.Promo.Code]++
+		s.customers[[2]string{d.Promo.Code, customerID}]++
+		s.redemptions[orderID] = append(s.redemptions[orderID], redemption{code: d.Promo.Code, customerID: customerID})
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(ctx context.Context, orderID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range s.redemptions[orderID] {
+		s.usage[r.code]--
+		s.customers[[2]string{r.code, r.customerID}]--
+	}
+	delete(s.redemptions, orderID)
+	return nil
+}
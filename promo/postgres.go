@@ -0,0 +1,145 @@
+package promo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Usage(ctx context.Context, code, customerID string) (Usage, error) {
+	var u Usage
+	err := s.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE((SELECT redemptions FROM promo_usage WHERE code = $1), 0),
+			COALESCE((SELECT redemptions FROM promo_customer_usage WHERE code = $1 AND customer_id = $2), 0)`,
+		code, customerID,
+	).Scan(&u.Redemptions, &u.CustomerRedemptions)
+	if err != nil {
+		return Usage{}, fmt.Errorf("select promo %s usage: %w", code, err)
+	}
+	return u, nil
+}
+
+// Redeem bumps each counter with an UPDATE that only matches below the
+// cap. Concurrent redemptions queue on the counter's row lock and re-check
+// the cap once it is released, so caps hold under any load.
+func (s *PostgresStore) Redeem(ctx context.Context, orderID, customerID string, discounts []Applied) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin redeem promos for %s: %w", orderID, err)
+	}
+	defer tx.Rollback()
+
+	for _, d := range discounts {
+		code := d.Promo.Code
+		if _, err := tx.ExecContext(ctx, `INSERT INTO promo_usage (code) VALUES ($1) ON CONFLICT DO NOTHING`, code); err != nil {
+			return fmt.Errorf("insert promo %s usage: %w", code, err)
+		}
+		res, err := tx.ExecContext(ctx, `
+			UPDATE promo_usage SET redemptions = redemptions + 1
+			WHERE code = $1 AND ($2 = 0 OR redemptions < $2)`,
+			code, d.Promo.MaxRedemptions,
+		)
+		if err := checkRedeemed(res, err, code, ""); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO promo_customer_usage (code, customer_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			code, customerID,
+		); err != nil {
+			return fmt.Errorf("insert promo %s customer usage: %w", code, err)
+		}
+		res, err = tx.ExecContext(ctx, `
+			UPDATE promo_customer_usage SET redemptions = redemptions + 1
+			WHERE code = $1 AND customer_id = $2 AND ($3 = 0 OR redemptions < $3)`,
+			code, customerID, d.Promo.MaxPerCustomer,
+		)
+		if err := checkRedeemed(res, err, code, " for this customer"); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO promo_redemptions (order_id, code, customer_id, amount)
+			VALUES ($1, $2, $3, $4)`,
+			orderID, code, customerID, d.Amount,
+		); err != nil {
+			return fmt.Errorf("insert promo %s redemption for %s: %w", code, orderID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit promo redemptions for %s: %w", orderID, err)
+	}
+	return nil
+}
+
+// checkRedeemed turns an UPDATE that matched no row into ErrExhausted.
+func checkRedeemed(res sql.Result, err error, code, scope string) error {
+	if err != nil {
+		return fmt.Errorf("update promo %s usage: %w", code, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update promo %s usage: %w", code, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s%s", ErrExhausted, code, scope)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, orderID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin release promos for %s: %w", orderID, err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM promo_redemptions WHERE order_id = $1
+		RETURNING code, customer_id`, orderID)
+	if err != nil {
+		return fmt.Errorf("delete promo redemptions for %s: %w", orderID, err)
+	}
+	type redemption struct{ code, customerID string }
+	var released []redemption
+	for rows.Next() {
+		var r redemption
+		if err := rows.Scan(&r.code, &r.customerID); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan promo redemption for %s: %w", orderID, err)
+		}
+		released = append(released, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("delete promo redemptions for %s: %w", orderID, err)
+	}
+
+	for _, r := range released {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE promo_usage SET redemptions = redemptions - 1
+			WHERE code = $1 AND redemptions > 0`, r.code); err != nil {
+			return fmt.Errorf("release promo %s: %w", r.code, err)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE promo_customer_usage SET redemptions = redemptions - 1
+			WHERE code = $1 AND customer_id = $2 AND redemptions > 0`, r.code, r.customerID); err != nil {
+			return fmt.Errorf("release promo %s: %w", r.code, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit promo release for %s: %w", orderID, err)
+	}
+	return nil
+}
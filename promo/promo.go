@@ -0,0 +1,102 @@
+// Package promo prices orders with marketing promotions: percentage or
+// fixed discounts limited to some books, customers and dates, with caps on
+// how often they can be redeemed.
+package promo
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("promo code not found")
+	// ErrNotApplicable wraps the reason a code cannot be used on an order.
+	ErrNotApplicable = errors.New("promo code cannot be used")
+	ErrExhausted     = errors.New("promo code has no redemptions left")
+)
+
+// Discount types.
+const (
+	TypePercent = "percent"
+	TypeFixed   = "fixed"
+)
+
+type Promo struct {
+	// Code is what customers enter, in upper case.
+	Code string
+	Name string
+	Type string
+	// Value is the percentage for percent promos and rupiah for fixed ones.
+	Value int64
+	// MaxDiscount caps a percent discount; zero leaves it uncapped.
+	MaxDiscount int64
+	// BookIDs and CustomerIDs limit who and what the promo is for; empty
+	// means everyone and everything.
+	BookIDs     []string
+	CustomerIDs []string
+	StartsAt    *time.Time
+	EndsAt      *time.Time
+	// MaxRedemptions and MaxPerCustomer cap redemptions overall and per
+	// customer; zero means no cap.
+	MaxRedemptions int
+	MaxPerCustomer int
+	// Stackable promos can be combined with other stackable promos.
+	Stackable bool
+	// Automatic promos apply without the customer entering the code.
+	Automatic bool
+}
+
+// Active reports whether t is inside the promo's validity window.
+func (p *Promo) Active(t time.Time) bool {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || t.Before(*p.EndsAt)
+}
+
+func (p *Promo) forBook(bookID string) bool {
+	return len(p.BookIDs) == 0 || slices.Contains(p.BookIDs, bookID)
+}
+
+func (p *Promo) forCustomer(customerID string) bool {
+	return len(p.CustomerIDs) == 0 || slices.Contains(p.CustomerIDs, customerID)
+}
+
+// Line is one priced line of the order.
+type Line struct {
+	BookID   string
+	Subtotal int64
+}
+
+// Applied is a promo and how much it takes off the order.
+type Applied struct {
+	Promo  *Promo
+	Amount int64
+}
+
+// Quote is an order's price after promotions.
+type Quote struct {
+	Subtotal  int64
+	Discounts []Applied
+	Total     int64
+}
+
+// Usage is how often a promo has been redeemed, overall and by one
+// customer.
+type Usage struct {
+	Redemptions         int
+	CustomerRedemptions int
+}
+
+// Store counts redemptions. Redeem takes every discount of one order at
+// once and fails with ErrExhausted, taking none, if any of them would go
+// over its caps.
+type Store interface {
+	Usage(ctx context.Context, code, customerID string) (Usage, error)
+	Redeem(ctx context.Context, orderID, customerID string, discounts []Applied) error
+	// Release gives back an order's redemptions. Releasing an order twice,
+	// or one without redemptions, does nothing.
+	Release(ctx context.Context, orderID string) error
+}
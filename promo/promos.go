@@ -0,0 +1,142 @@
+package promo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Promos is the fixed set of promotions marketing has configured.
+type Promos struct {
+	promos map[string]Promo
+}
+
+func NewPromos(promos []Promo) *Promos {
+	p := &Promos{promos: make(map[string]Promo, len(promos))}
+	for _, promo := range promos {
+		p.promos[promo.Code] = promo
+	}
+	return p
+}
+
+// LoadPromos reads promotions from a JSON file keyed by code, e.g.
+//
+//	{"READ10": {"name": "10% off", "type": "percent", "value": 10,
+//	            "max_per_customer": 1, "ends_at": "2026-12-31T17:00:00Z"}}
+func LoadPromos(path string) (*Promos, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read promo codes: %w", err)
+	}
+
+	var entries map[string]struct {
+		Name           string     `json:"name"`
+		Type           string     `json:"type"`
+		Value          int64      `json:"value"`
+		MaxDiscount    int64      `json:"max_discount"`
+		BookIDs        []string   `json:"book_ids"`
+		CustomerIDs    []string   `json:"customer_ids"`
+		StartsAt       *time.Time `json:"starts_at"`
+		EndsAt         *time.Time `json:"ends_at"`
+		MaxRedemptions int        `json:"max_redemptions"`
+		MaxPerCustomer int        `json:"max_per_customer"`
+		Stackable      bool       `json:"stackable"`
+		Automatic      bool       `json:"automatic"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse promo codes %s: %w", path, err)
+	}
+
+	promos := make([]Promo, 0, len(entries))
+	seen := make(map[string]bool, len(entries))
+	for code, e := range entries {
+		p := Promo{
+			Code:           strings.ToUpper(strings.TrimSpace(code)),
+			Name:           e.Name,
+			Type:           e.Type,
+			Value:          e.Value,
+			MaxDiscount:    e.MaxDiscount,
+			BookIDs:        e.BookIDs,
+			CustomerIDs:    e.CustomerIDs,
+			StartsAt:       e.StartsAt,
+			EndsAt:         e.EndsAt,
+			MaxRedemptions: e.MaxRedemptions,
+			MaxPerCustomer: e.MaxPerCustomer,
+			Stackable:      e.Stackable,
+			Automatic:      e.Automatic,
+		}
+		if seen[p.Code] {
+			return nil, fmt.Errorf("promo codes %s: code %s is listed twice", path, p.Code)
+		}
+		seen[p.Code] = true
+		if err := p.validate(); err != nil {
+			return nil, fmt.Errorf("promo codes %s: code %s: %w", path, code, err)
+		}
+		promos = append(promos, p)
+	}
+	return NewPromos(promos), nil
+}
+
+func (p Promo) validate() error {
+	var problems []string
+	if p.Code == "" {
+		problems = append(problems, "code must not be empty")
+	}
+	if strings.TrimSpace(p.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	switch p.Type {
+	case TypePercent:
+		if p.Value < 1 || p.Value > 100 {
+			problems = append(problems, "value must be a percentage from 1 to 100")
+		}
+	case TypeFixed:
+		if p.Value <= 0 {
+			problems = append(problems, "value must be positive")
+		}
+		if p.MaxDiscount != 0 {
+			problems = append(problems, "max_discount only applies to percent promos")
+		}
+	default:
+		problems = append(problems, "type must be percent or fixed")
+	}
+	if p.MaxDiscount < 0 {
+		problems = append(problems, "max_discount cannot be negative")
+	}
+	if p.MaxRedemptions < 0 || p.MaxPerCustomer < 0 {
+		problems = append(problems, "max_redemptions and max_per_customer cannot be negative")
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		problems = append(problems, "ends_at must be after starts_at")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Get looks a code up case-insensitively.
+func (p *Promos) Get(code string) (*Promo, error) {
+	promo, ok := p.promos[strings.ToUpper(strings.TrimSpace(code))]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &promo, nil
+}
+
+// Automatic returns the promos applied without a code, ordered by code.
+func (p *Promos) Automatic() []*Promo {
+	var promos []*Promo
+	for _, promo := range p.promos {
+		if promo.Automatic {
+			promos = append(promos, &promo)
+		}
+	}
+	slices.SortFunc(promos, func(a, b *Promo) int {
+		return strings.Compare(a.Code, b.Code)
+	})
+	return promos
+}
@@ -0,0 +1,31 @@
+package promo
+
+import (
+	"context"
+	"slices"
+
+	"payment-service-iae/outbox"
+	"payment-service-iae/payment"
+)
+
+// unpaidEvents end a payment without it ever being paid. A denial is not
+// among them: Snap lets the customer retry with another card, and the
+// discount still applies if that one is paid.
+var unpaidEvents = []string{payment.EventCancelled, payment.EventExpired, payment.EventFailed}
+
+// Releaser is the outbox broker that gives back the redemptions of
+// payments that end unpaid, so their customers can use the code again.
+type Releaser struct {
+	service *Service
+}
+
+func NewReleaser(service *Service) *Releaser {
+	return &Releaser{service: service}
+}
+
+func (r *Releaser) Publish(ctx context.Context, e outbox.Event) error {
+	if !slices.Contains(unpaidEvents, e.Type) {
+		return nil
+	}
+	return r.service.Release(ctx, e.AggregateID)
+}
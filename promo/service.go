@@ -0,0 +1,193 @@
+package promo
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Service prices orders with the configured promos and keeps count of
+// their redemptions.
+//
+// Stacking: an entered code always applies when it is valid. If it is
+// stackable, every eligible stackable automatic promo is added to it;
+// otherwise it applies alone. Without a code, the customer gets whichever
+// is cheaper: all eligible stackable automatic promos together, or the
+// best non-stackable one alone. Percentage discounts are taken before fixed
+// ones, each from what the earlier ones left.
+type Service struct {
+	store  Store
+	promos *Promos
+	now    func() time.Time
+}
+
+func NewService(store Store, promos *Promos) *Service {
+	return &Service{store: store, promos: promos, now: time.Now}
+}
+
+// Quote prices the order's lines for customerID with code, which may be
+// empty. Errors wrap ErrNotFound, ErrNotApplicable or ErrExhausted when the
+// code cannot be used.
+func (s *Service) Quote(ctx context.Context, customerID string, lines []Line, code string) (*Quote, error) {
+	now := s.now()
+
+	var entered *Promo
+	if code != "" {
+		p, err := s.promos.Get(code)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.check(ctx, p, customerID, lines, now); err != nil {
+			return nil, err
+		}
+		entered = p
+	}
+
+	var stackable, exclusive []*Promo
+	for _, p := range s.promos.Automatic() {
+		if entered != nil && p.Code == entered.Code {
+			continue
+		}
+		err := s.check(ctx, p, customerID, lines, now)
+		if errors.Is(err, ErrNotApplicable) || errors.Is(err, ErrExhausted) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.Stackable {
+			stackable = append(stackable, p)
+		} else {
+			exclusive = append(exclusive, p)
+		}
+	}
+
+	var q *Quote
+	switch {
+	case entered != nil && entered.Stackable:
+		q = apply(lines, append([]*Promo{entered}, stackable...))
+	case entered != nil:
+		q = apply(lines, []*Promo{entered})
+	default:
+		q = apply(lines, stackable)
+		for _, p := range exclusive {
+			if alone := apply(lines, []*Promo{p}); alone.Total < q.Total {
+				q = alone
+			}
+		}
+	}
+
+	if entered != nil && !slices.ContainsFunc(q.Discounts, func(a Applied) bool { return a.Promo.Code == entered.Code }) {
+		return nil, fmt.Errorf("%w: %s does not lower the price of this order", ErrNotApplicable, entered.Code)
+	}
+	if q.Total <= 0 {
+		return nil, fmt.Errorf("%w: discounts cannot cover the whole order", ErrNotApplicable)
+	}
+	return q, nil
+}
+
+// Redeem counts the quote's discounts against their caps for the order.
+func (s *Service) Redeem(ctx context.Context, orderID, customerID string, q *Quote) error {
+	if len(q.Discounts) == 0 {
+		return nil
+	}
+	return s.store.Redeem(ctx, orderID, customerID, q.Discounts)
+}
+
+// Release gives back the order's redemptions, for orders that will never
+// be paid.
+func (s *Service) Release(ctx context.Context, orderID string) error {
+	return s.store.Release(ctx, orderID)
+}
+
+func (s *Service) check(ctx context.Context, p *Promo, customerID string, lines []Line, now time.Time) error {
+	if !p.Active(now) {
+		return fmt.Errorf("%w: %s is not valid at this time", ErrNotApplicable, p.Code)
+	}
+	if !p.forCustomer(customerID) {
+		return fmt.Errorf("%w: %s is not available to this customer", ErrNotApplicable, p.Code)
+	}
+	if !slices.ContainsFunc(lines, func(l Line) bool { return p.forBook(l.BookID) }) {
+		return fmt.Errorf("%w: %s does not apply to these books", ErrNotApplicable, p.Code)
+	}
+	if p.MaxRedemptions == 0 && p.MaxPerCustomer == 0 {
+		return nil
+	}
+	// Only a hint: Redeem enforces the caps atomically.
+	usage, err := s.store.Usage(ctx, p.Code, customerID)
+	if err != nil {
+		return err
+	}
+	if p.MaxRedemptions > 0 && usage.Redemptions >= p.MaxRedemptions {
+		return fmt.Errorf("%w: %s", ErrExhausted, p.Code)
+	}
+	if p.MaxPerCustomer > 0 && usage.CustomerRedemptions >= p.MaxPerCustomer {
+		return fmt.Errorf("%w: %s for this customer", ErrExhausted, p.Code)
+	}
+	return nil
+}
+
+// apply takes promos off the lines in order: percentages first, then
+// fixed amounts. Each discount comes off the eligible lines' remaining
+// amounts, so discounts together never exceed the subtotal.
+func apply(lines []Line, promos []*Promo) *Quote {
+	remaining := make([]int64, len(lines))
+	q := &Quote{}
+	for i, l := range lines {
+		remaining[i] = l.Subtotal
+		q.Subtotal += l.Subtotal
+	}
+
+	ordered := slices.Clone(promos)
+	slices.SortStableFunc(ordered, func(a, b *Promo) int {
+		return cmp.Compare(typeOrder(a.Type), typeOrder(b.Type))
+	})
+
+	var discount int64
+	for _, p := range ordered {
+		var base int64
+		for i, l := range lines {
+			if p.forBook(l.BookID) {
+				base += remaining[i]
+			}
+		}
+
+		amount := min(p.Value, base)
+		if p.Type == TypePercent {
+			// Rounded down, split to keep base*Value from overflowing.
+			amount = base/100*p.Value + base%100*p.Value/100
+			if p.MaxDiscount > 0 {
+				amount = min(amount, p.MaxDiscount)
+			}
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		left := amount
+		for i, l := range lines {
+			if left == 0 {
+				break
+			}
+			if p.forBook(l.BookID) {
+				take := min(left, remaining[i])
+				remaining[i] -= take
+				left -= take
+			}
+		}
+		q.Discounts = append(q.Discounts, Applied{Promo: p, Amount: amount})
+		discount += amount
+	}
+	q.Total = q.Subtotal - discount
+	return q
+}
+
+func typeOrder(t string) int {
+	if t == TypePercent {
+		return 0
+	}
+	return 1
+}
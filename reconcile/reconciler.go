@@ -0,0 +1,124 @@
+// Package reconcile keeps stored payments in line with the gateway: it
+// catches up on notifications that never arrived, settles refunds whose
+// outcome was unknown and expires payments that were not paid in time.
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"payment-service-iae/gateway"
+	"payment-service-iae/payment"
+)
+
+// Reconciler periodically checks payments that have been pending for longer
+// than a threshold against the gateway and applies what it reports through
+// payment.Service, the same state machine notifications go through.
+type Reconciler struct {
+	payments  *payment.Service
+	gateway   gateway.Gateway
+	after     time.Duration
+	interval  time.Duration
+	jitter    time.Duration
+	batchSize int
+	stats     *stats
+}
+
+// NewReconciler checks up to batchSize payments that have been pending for
+// at least after, every interval plus a random delay of up to jitter so that
+// several instances do not poll the gateway in lockstep.
+func NewReconciler(payments *payment.Service, gw gateway.Gateway, after, interval, jitter time.Duration, batchSize int) *Reconciler {
+	return &Reconciler{
+		payments:  payments,
+		gateway:   gw,
+		after:     after,
+		interval:  interval,
+		jitter:    jitter,
+		batchSize: batchSize,
+		stats:     newStats(),
+	}
+}
+
+func (r *Reconciler) Run(ctx context.Context) {
+	timer := time.NewTimer(r.nextDelay())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		if _, err := r.RunOnce(ctx); err != nil {
+			log.Printf("Reconciler: %v", err)
+		}
+		timer.Reset(r.nextDelay())
+	}
+}
+
+func (r *Reconciler) nextDelay() time.Duration {
+	if r.jitter <= 0 {
+		return r.interval
+	}
+	return r.interval + rand.N(r.jitter)
+}
+
+// RunOnce checks one batch of stale pending payments and returns how many
+// were checked, then settles a batch of stale pending refunds.
+func (r *Reconciler) RunOnce(ctx context.Context) (int, error) {
+	orderIDs, err := r.payments.ClaimStalePending(ctx, r.after, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, orderID := range orderIDs {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		r.stats.record(r.reconcile(ctx, orderID))
+	}
+	r.stats.finishRun(len(orderIDs))
+
+	if err := r.reconcileRefunds(ctx); err != nil {
+		return len(orderIDs), err
+	}
+	return len(orderIDs), nil
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, orderID string) outcome {
+	status, err := r.gateway.Status(ctx, orderID)
+	if gateway.IsKind(err, gateway.KindNotFound) {
+		// The customer has not picked a payment method yet.
+		return outcome{kind: outcomeNotStarted}
+	}
+	if err != nil {
+		log.Printf("Reconciler: status of %s: %v", orderID, err)
+		return outcome{kind: outcomeFailed}
+	}
+
+	p, changed, err := r.payments.ApplyStatusUpdate(ctx, orderID, payment.UpdateFromGateway(status))
+	switch {
+	case errors.Is(err, payment.ErrInvalidTransition):
+		log.Printf("Reconciler: ignored gateway status for %s: %v", orderID, err)
+		return outcome{kind: outcomeSkipped}
+	case errors.Is(err, payment.ErrAmountMismatch):
+		log.Printf("Reconciler: gateway amount for %s does not match: %v", orderID, err)
+		return outcome{kind: outcomeFailed}
+	case err != nil:
+		log.Printf("Reconciler: applying status to %s: %v", orderID, err)
+		return outcome{kind: outcomeFailed}
+	case changed:
+		log.Printf("Reconciler: %s moved to %s", orderID, p.Status)
+		return outcome{kind: outcomeCorrected, status: p.Status}
+	}
+	return outcome{kind: outcomeUnchanged}
+}
+
+// Stats returns a snapshot of what the reconciler has done since start.
+func (r *Reconciler) Stats() Stats {
+	return r.stats.snapshot()
+}
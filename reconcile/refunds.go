@@ -0,0 +1,67 @@
+package reconcile
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"payment-service-iae/payment"
+)
+
+// reconcileRefunds settles refunds left pending because the gateway call
+// ended without a definite answer. Midtrans lists every refund it made with
+// the transaction, so a refund still missing there long after its call was
+// never made, and its amount is released.
+func (r *Reconciler) reconcileRefunds(ctx context.Context) error {
+	orderIDs, err := r.payments.StalePendingRefunds(ctx, r.after, r.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, orderID := range orderIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		r.reconcileRefundsOf(ctx, orderID)
+	}
+	return nil
+}
+
+func (r *Reconciler) reconcileRefundsOf(ctx context.Context, orderID string) {
+	p, err := r.payments.Get(ctx, orderID)
+	if err != nil {
+		log.Printf("Reconciler: loading %s for its refunds: %v", orderID, err)
+		r.stats.record(outcome{kind: outcomeFailed})
+		return
+	}
+	status, err := r.gateway.Status(ctx, orderID)
+	if err != nil {
+		log.Printf("Reconciler: status of %s for its refunds: %v", orderID, err)
+		r.stats.record(outcome{kind: outcomeFailed})
+		return
+	}
+
+	cutoff := time.Now().Add(-r.after)
+	for _, refund := range p.Refunds {
+		if refund.Status != payment.RefundPending || !refund.UpdatedAt.Before(cutoff) {
+			continue
+		}
+		if made := status.Refund(refund.RefundKey); made != nil {
+			if _, err := r.payments.CompleteRefund(ctx, orderID, refund.RefundKey, made.RefundID); err != nil {
+				log.Printf("Reconciler: completing refund %s of %s: %v", refund.RefundKey, orderID, err)
+				r.stats.record(outcome{kind: outcomeFailed})
+				continue
+			}
+			log.Printf("Reconciler: refund %s of %s was made at the gateway", refund.RefundKey, orderID)
+			r.stats.recordRefund(true)
+			continue
+		}
+		if err := r.payments.FailRefund(ctx, orderID, refund.RefundKey); err != nil {
+			log.Printf("Reconciler: releasing refund %s of %s: %v", refund.RefundKey, orderID, err)
+			r.stats.record(outcome{kind: outcomeFailed})
+			continue
+		}
+		log.Printf("Reconciler: refund %s of %s was never made, released", refund.RefundKey, orderID)
+		r.stats.recordRefund(false)
+	}
+}
@@ -0,0 +1,102 @@
+package reconcile
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"payment-service-iae/payment"
+)
+
+// Stats are cumulative counters, published at /debug/vars.
+type Stats struct {
+	Runs    int64 `json:"runs"`
+	Checked int64 `json:"checked"`
+	// Corrected payments had a status change the service had missed.
+	Corrected         int64            `json:"corrected"`
+	CorrectedByStatus map[string]int64 `json:"corrected_by_status"`
+	Unchanged         int64            `json:"unchanged"`
+	// NotStarted payments are unknown to the gateway because the customer
+	// has not chosen a payment method yet.
+	NotStarted int64 `json:"not_started"`
+	// Skipped payments reported a status the state machine does not allow.
+	Skipped int64 `json:"skipped"`
+	// RefundsCompleted and RefundsReleased are pending refunds the gateway
+	// turned out to have made, or not.
+	RefundsCompleted int64     `json:"refunds_completed"`
+	RefundsReleased  int64     `json:"refunds_released"`
+	Failed           int64     `json:"failed"`
+	LastRunAt        time.Time `json:"last_run_at"`
+}
+
+type outcomeKind int
+
+const (
+	outcomeUnchanged outcomeKind = iota
+	outcomeCorrected
+	outcomeNotStarted
+	outcomeSkipped
+	outcomeFailed
+)
+
+type outcome struct {
+	kind   outcomeKind
+	status payment.Status
+}
+
+type stats struct {
+	mu sync.Mutex
+	s  Stats
+}
+
+func newStats() *stats {
+	return &stats{s: Stats{CorrectedByStatus: make(map[string]int64)}}
+}
+
+func (s *stats) record(o outcome) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch o.kind {
+	case outcomeUnchanged:
+		s.s.Unchanged++
+	case outcomeCorrected:
+		s.s.Corrected++
+		s.s.CorrectedByStatus[string(o.status)]++
+	case outcomeNotStarted:
+		s.s.NotStarted++
+	case outcomeSkipped:
+		s.s.Skipped++
+	case outcomeFailed:
+		s.s.Failed++
+	}
+}
+
+func (s *stats) recordRefund(completed bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if completed {
+		s.s.RefundsCompleted++
+	} else {
+		s.s.RefundsReleased++
+	}
+}
+
+func (s *stats) finishRun(checked int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.s.Runs++
+	s.s.Checked += int64(checked)
+	s.s.LastRunAt = time.Now()
+}
+
+func (s *stats) snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.s
+	c.CorrectedByStatus = maps.Clone(s.s.CorrectedByStatus)
+	return c
+}
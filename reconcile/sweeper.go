@@ -0,0 +1,143 @@
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"payment-service-iae/gateway"
+	"payment-service-iae/payment"
+)
+
+// Sweeper expires payments that are still pending after their expiry. Each
+// one is checked with the gateway first so a payment made just before the
+// deadline is recorded as paid rather than expired, and a transaction the
+// gateway still holds open is cancelled there so it cannot be paid later.
+type Sweeper struct {
+	payments  *payment.Service
+	gateway   gateway.Gateway
+	grace     time.Duration
+	interval  time.Duration
+	batchSize int
+
+	mu    sync.Mutex
+	stats SweepStats
+}
+
+// SweepStats are cumulative counters, published at /debug/vars.
+type SweepStats struct {
+	Runs    int64 `json:"runs"`
+	Expired int64 `json:"expired"`
+	// Resolved payments had reached another final status at the gateway.
+	Resolved  int64     `json:"resolved"`
+	Failed    int64     `json:"failed"`
+	LastRunAt time.Time `json:"last_run_at"`
+}
+
+// NewSweeper expires up to batchSize payments every interval, once their
+// expiry is more than grace in the past. The grace period gives a late
+// notification for a payment made at the last moment time to arrive.
+func NewSweeper(payments *payment.Service, gw gateway.Gateway, grace, interval time.Duration, batchSize int) *Sweeper {
+	return &Sweeper{
+		payments:  payments,
+		gateway:   gw,
+		grace:     grace,
+		interval:  interval,
+		batchSize: batchSize,
+	}
+}
+
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.RunOnce(ctx); err != nil {
+			log.Printf("Expiry sweeper: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce handles one batch of expired payments and returns how many were
+// looked at.
+func (s *Sweeper) RunOnce(ctx context.Context) (int, error) {
+	orderIDs, err := s.payments.ExpiredPending(ctx, s.grace, s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, orderID := range orderIDs {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		s.sweep(ctx, orderID)
+	}
+
+	s.mu.Lock()
+	s.stats.Runs++
+	s.stats.LastRunAt = time.Now()
+	s.mu.Unlock()
+	return len(orderIDs), nil
+}
+
+func (s *Sweeper) sweep(ctx context.Context, orderID string) {
+	status, err := s.gateway.Status(ctx, orderID)
+	switch {
+	case gateway.IsKind(err, gateway.KindNotFound):
+		// The customer never picked a payment method.
+	case err != nil:
+		log.Printf("Expiry sweeper: status of %s: %v", orderID, err)
+		s.count(&s.stats.Failed)
+		return
+	case status.Status == gateway.StatusPending:
+		if _, err := s.gateway.Cancel(ctx, orderID); err != nil && !gateway.IsKind(err, gateway.KindNotFound) {
+			log.Printf("Expiry sweeper: cancelling %s at the gateway: %v", orderID, err)
+			s.count(&s.stats.Failed)
+			return
+		}
+	default:
+		s.apply(ctx, orderID, payment.UpdateFromGateway(status))
+		return
+	}
+
+	s.apply(ctx, orderID, payment.StatusUpdate{Status: payment.StatusExpire})
+}
+
+func (s *Sweeper) apply(ctx context.Context, orderID string, u payment.StatusUpdate) {
+	p, changed, err := s.payments.ApplyStatusUpdate(ctx, orderID, u)
+	switch {
+	case errors.Is(err, payment.ErrInvalidTransition):
+		log.Printf("Expiry sweeper: ignored status for %s: %v", orderID, err)
+		s.count(&s.stats.Failed)
+	case err != nil:
+		log.Printf("Expiry sweeper: applying status to %s: %v", orderID, err)
+		s.count(&s.stats.Failed)
+	case !changed:
+	case p.Status == payment.StatusExpire:
+		log.Printf("Expiry sweeper: %s expired", orderID)
+		s.count(&s.stats.Expired)
+	default:
+		log.Printf("Expiry sweeper: %s moved to %s at the gateway", orderID, p.Status)
+		s.count(&s.stats.Resolved)
+	}
+}
+
+func (s *Sweeper) count(counter *int64) {
+	s.mu.Lock()
+	*counter++
+	s.mu.Unlock()
+}
+
+// Stats returns a snapshot of what the sweeper has done since start.
+func (s *Sweeper) Stats() SweepStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
@@ -0,0 +1,81 @@
+package savedmethod
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryStore is an in-process Store for tests and local development.
+type MemoryStore struct {
+	mu      sync.Mutex
+	methods []Method
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Save(ctx context.Context, m *Method) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	i := slices.IndexFunc(s.methods, func(e Method) bool {
+		return e.CustomerID == m.CustomerID && e.MaskedCard == m.MaskedCard
+	})
+	if i >= 0 {
+		m.ID = s.methods[i].ID
+		m.CreatedAt = s.methods[i].CreatedAt
+		m.UpdatedAt = now
+		s.methods[i] = *m
+		return nil
+	}
+	m.ID = uuid.NewString()
+	m.CreatedAt = now
+	m.UpdatedAt = now
+	s.methods = append(s.methods, *m)
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, id string) (*Method, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.methods, func(m Method) bool { return m.ID == id })
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	m := s.methods[i]
+	return &m, nil
+}
+
+func (s *MemoryStore) ListByCustomer(ctx context.Context, customerID string) ([]Method, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var out []Method
+	for _, m := range s.methods {
+		if m.CustomerID == customerID {
+			out = append(out, m)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b Method) int { return cmp.Compare(b.UpdatedAt.UnixNano(), a.UpdatedAt.UnixNano()) })
+	return out, nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, customerID, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.methods, func(m Method) bool { return m.ID == id && m.CustomerID == customerID })
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.methods = slices.Delete(s.methods, i, i+1)
+	return nil
+}
@@ -0,0 +1,100 @@
+package savedmethod
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+const methodColumns = `id, customer_id, payment_type, token, token_expires_at, masked_card, card_type, bank,
+	created_at, updated_at`
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Save(ctx context.Context, m *Method) error {
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO saved_payment_methods (id, customer_id, payment_type, token, token_expires_at, masked_card, card_type, bank)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (customer_id, masked_card) DO UPDATE
+		SET payment_type = EXCLUDED.payment_type, token = EXCLUDED.token, token_expires_at = EXCLUDED.token_expires_at,
+			card_type = EXCLUDED.card_type, bank = EXCLUDED.bank, updated_at = NOW()
+		RETURNING id, created_at, updated_at`,
+		uuid.NewString(), m.CustomerID, m.PaymentType, m.Token, m.TokenExpiresAt, m.MaskedCard, m.CardType, m.Bank,
+	).Scan(&m.ID, &m.CreatedAt, &m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("save payment method for %s: %w", m.CustomerID, err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Method, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+methodColumns+` FROM saved_payment_methods WHERE id = $1`, id)
+	m, err := scanMethod(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select saved payment method %s: %w", id, err)
+	}
+	return m, nil
+}
+
+func (s *PostgresStore) ListByCustomer(ctx context.Context, customerID string) ([]Method, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+methodColumns+` FROM saved_payment_methods
+		WHERE customer_id = $1
+		ORDER BY updated_at DESC`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("select saved payment methods: %w", err)
+	}
+	defer rows.Close()
+
+	var methods []Method
+	for rows.Next() {
+		m, err := scanMethod(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan saved payment method: %w", err)
+		}
+		methods = append(methods, *m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select saved payment methods: %w", err)
+	}
+	return methods, nil
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, customerID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM saved_payment_methods WHERE id = $1 AND customer_id = $2`, id, customerID)
+	if err != nil {
+		return fmt.Errorf("delete saved payment method %s: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMethod(row rowScanner) (*Method, error) {
+	m := &Method{}
+	err := row.Scan(
+		&m.ID, &m.CustomerID, &m.PaymentType, &m.Token, &m.TokenExpiresAt, &m.MaskedCard, &m.CardType, &m.Bank,
+		&m.CreatedAt, &m.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
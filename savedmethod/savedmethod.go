@@ -0,0 +1,74 @@
+// Package savedmethod keeps the reusable card tokens the gateway issues when
+// a customer asks to save their card, so later payments can skip entering
+// the card again. Only the token and the masked card number are stored.
+package savedmethod
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"payment-service-iae/payment"
+)
+
+var (
+	ErrNotFound = errors.New("saved payment method not found")
+	ErrExpired  = errors.New("saved payment method has expired")
+)
+
+// PaymentTypeCard is the only type that can be saved today.
+const PaymentTypeCard = "credit_card"
+
+type Method struct {
+	ID          string
+	CustomerID  string
+	PaymentType string
+	// Token is the gateway's saved_token_id; it is never shown to clients.
+	Token          string
+	TokenExpiresAt *time.Time
+	// MaskedCard is the first six and last four digits, e.g. 481111-1114.
+	MaskedCard string
+	CardType   string
+	Bank       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Expired reports whether the token can no longer be charged at now.
+func (m *Method) Expired(now time.Time) bool {
+	return m.TokenExpiresAt != nil && !now.Before(*m.TokenExpiresAt)
+}
+
+// Store keeps saved methods per customer. Saving a card the customer has
+// already saved (same masked number) replaces its token and keeps its ID.
+type Store interface {
+	Save(ctx context.Context, m *Method) error
+	Get(ctx context.Context, id string) (*Method, error)
+	// ListByCustomer returns the customer's methods, most recently saved
+	// first.
+	ListByCustomer(ctx context.Context, customerID string) ([]Method, error)
+	// Delete removes the customer's method; another customer's method is
+	// ErrNotFound.
+	Delete(ctx context.Context, customerID, id string) error
+}
+
+// SaveCards is a payment.PaidHook that keeps the token the gateway issued
+// for a paid payment whose customer asked to save their card. Repeated
+// updates just refresh it.
+func SaveCards(store Store) payment.PaidHook {
+	return func(ctx context.Context, p *payment.Payment, u payment.StatusUpdate) error {
+		card := u.SavedCard
+		if card == nil || p.Options == nil || p.Options.CreditCard == nil || !p.Options.CreditCard.SaveCard {
+			return nil
+		}
+		return store.Save(ctx, &Method{
+			CustomerID:     p.CustomerID,
+			PaymentType:    PaymentTypeCard,
+			Token:          card.Token,
+			TokenExpiresAt: card.ExpiresAt,
+			MaskedCard:     card.MaskedCard,
+			CardType:       card.CardType,
+			Bank:           card.Bank,
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package subscription
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store for tests and local development.
+type MemoryStore struct {
+	mu            sync.Mutex
+	subscriptions []Subscription
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (m *MemoryStore) Create(ctx context.Context, s *Subscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.subscriptions {
+		if existing.ID == s.ID {
+			return ErrAlreadySubscribed
+		}
+		if s.Status.Live() && existing.Status.Live() && existing.CustomerID == s.CustomerID && existing.PlanID == s.PlanID {
+			return ErrAlreadySubscribed
+		}
+	}
+	now := time.Now()
+	s.CreatedAt = now
+	s.UpdatedAt = now
+	m.subscriptions = append(m.subscriptions, *s)
+	return nil
+}
+
+func (m *MemoryStore) Get(ctx context.Context, id string) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.subscriptions, func(s Subscription) bool { return s.ID == id })
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	s := m.subscriptions[i]
+	return &s, nil
+}
+
+func (m *MemoryStore) ListByCustomer(ctx context.Context, customerID string) ([]Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var out []Subscription
+	for i := len(m.subscriptions) - 1; i >= 0; i-- {
+		if m.subscriptions[i].CustomerID == customerID {
+			out = append(out, m.subscriptions[i])
+		}
+	}
+	return out, nil
+}
+
+func (m *MemoryStore) Update(ctx context.Context, id string, fn func(s *Subscription) error) (*Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.subscriptions, func(s Subscription) bool { return s.ID == id })
+	if i < 0 {
+		return nil, ErrNotFound
+	}
+	s := m.subscriptions[i]
+	if err := fn(&s); err != nil {
+		return nil, err
+	}
+	s.UpdatedAt = time.Now()
+	m.subscriptions[i] = s
+	return &s, nil
+}
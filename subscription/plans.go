@@ -0,0 +1,119 @@
+package subscription
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"payment-service-iae/money"
+)
+
+// Plan is a subscription product: what is charged and how often.
+type Plan struct {
+	ID           string
+	Name         string
+	Price        money.Money
+	Interval     int
+	IntervalUnit string
+	// MaxInterval limits how many times the plan is charged; zero means
+	// until cancelled.
+	MaxInterval int
+}
+
+// Next returns the charge after one at t.
+func (p Plan) Next(t time.Time) time.Time {
+	return addInterval(t, p.Interval, p.IntervalUnit)
+}
+
+// Plans is the fixed set of plans on sale.
+type Plans struct {
+	plans map[string]Plan
+}
+
+func NewPlans(plans []Plan) *Plans {
+	p := &Plans{plans: make(map[string]Plan, len(plans))}
+	for _, plan := range plans {
+		p.plans[plan.ID] = plan
+	}
+	return p
+}
+
+// LoadPlans reads plans from a JSON file of the form below; prices are in
+// rupiah.
+//
+//	{"monthly": {"name": "Monthly Reader", "price": 49000,
+//	             "interval": 1, "interval_unit": "month"}}
+func LoadPlans(path string) (*Plans, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read subscription plans: %w", err)
+	}
+
+	var entries map[string]struct {
+		Name         string `json:"name"`
+		Price        int64  `json:"price"`
+		Interval     int    `json:"interval"`
+		IntervalUnit string `json:"interval_unit"`
+		MaxInterval  int    `json:"max_interval"`
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parse subscription plans %s: %w", path, err)
+	}
+
+	plans := make([]Plan, 0, len(entries))
+	for id, e := range entries {
+		plan := Plan{ID: id, Name: e.Name, Price: money.Rupiah(e.Price), Interval: e.Interval, IntervalUnit: e.IntervalUnit, MaxInterval: e.MaxInterval}
+		if err := plan.validate(); err != nil {
+			return nil, fmt.Errorf("subscription plans %s: plan %s: %w", path, id, err)
+		}
+		plans = append(plans, plan)
+	}
+	return NewPlans(plans), nil
+}
+
+func (p Plan) validate() error {
+	var problems []string
+	if strings.TrimSpace(p.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if !p.Price.IsPositive() {
+		problems = append(problems, "price must be positive")
+	}
+	if p.Interval < 1 {
+		problems = append(problems, "interval must be at least 1")
+	}
+	if p.IntervalUnit != UnitDay && p.IntervalUnit != UnitWeek && p.IntervalUnit != UnitMonth {
+		problems = append(problems, "interval_unit must be day, week or month")
+	}
+	if p.MaxInterval < 0 {
+		problems = append(problems, "max_interval cannot be negative")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (p *Plans) Get(id string) (*Plan, error) {
+	plan, ok := p.plans[id]
+	if !ok {
+		return nil, ErrPlanNotFound
+	}
+	return &plan, nil
+}
+
+// List returns every plan, cheapest first.
+func (p *Plans) List() []Plan {
+	plans := make([]Plan, 0, len(p.plans))
+	for _, plan := range p.plans {
+		plans = append(plans, plan)
+	}
+	slices.SortFunc(plans, func(a, b Plan) int {
+		return cmp.Or(cmp.Compare(a.Price.Amount, b.Price.Amount), strings.Compare(a.ID, b.ID))
+	})
+	return plans
+}
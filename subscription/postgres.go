@@ -0,0 +1,137 @@
+package subscription
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const uniqueViolation = "23505"
+
+const subscriptionColumns = `id, plan_id, customer_id, gateway_id, payment_type, amount, currency, interval_count,
+	interval_unit, max_interval, status, next_charge_at, last_charged_at, last_order_id, charge_count,
+	cancelled_at, created_at, updated_at`
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (p *PostgresStore) Create(ctx context.Context, s *Subscription) error {
+	err := p.db.QueryRowContext(ctx, `
+		INSERT INTO subscriptions (id, plan_id, customer_id, gateway_id, payment_type, amount, currency, interval_count,
+			interval_unit, max_interval, status, next_charge_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING created_at, updated_at`,
+		s.ID, s.PlanID, s.CustomerID, s.GatewayID, s.PaymentType, s.Amount.Amount, s.Amount.Currency, s.Interval,
+		s.IntervalUnit, s.MaxInterval, s.Status, s.NextChargeAt,
+	).Scan(&s.CreatedAt, &s.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrAlreadySubscribed
+	}
+	if err != nil {
+		return fmt.Errorf("insert subscription %s: %w", s.ID, err)
+	}
+	return nil
+}
+
+func (p *PostgresStore) Get(ctx context.Context, id string) (*Subscription, error) {
+	row := p.db.QueryRowContext(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1`, id)
+	s, err := scanSubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select subscription %s: %w", id, err)
+	}
+	return s, nil
+}
+
+func (p *PostgresStore) ListByCustomer(ctx context.Context, customerID string) ([]Subscription, error) {
+	rows, err := p.db.QueryContext(ctx, `
+		SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE customer_id = $1
+		ORDER BY created_at DESC`, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("select subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []Subscription
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+func (p *PostgresStore) Update(ctx context.Context, id string, fn func(s *Subscription) error) (*Subscription, error) {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin update subscription %s: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions WHERE id = $1 FOR UPDATE`, id)
+	s, err := scanSubscription(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select subscription %s: %w", id, err)
+	}
+
+	if err := fn(s); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE subscriptions
+		SET status = $2, next_charge_at = $3, last_charged_at = $4, last_order_id = $5,
+			charge_count = $6, cancelled_at = $7, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		id, s.Status, s.NextChargeAt, s.LastChargedAt, s.LastOrderID, s.ChargeCount, s.CancelledAt,
+	).Scan(&s.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("update subscription %s: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit subscription %s: %w", id, err)
+	}
+	return s, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner) (*Subscription, error) {
+	s := &Subscription{}
+	err := row.Scan(
+		&s.ID, &s.PlanID, &s.CustomerID, &s.GatewayID, &s.PaymentType, &s.Amount.Amount, &s.Amount.Currency, &s.Interval,
+		&s.IntervalUnit, &s.MaxInterval, &s.Status, &s.NextChargeAt, &s.LastChargedAt, &s.LastOrderID, &s.ChargeCount,
+		&s.CancelledAt, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
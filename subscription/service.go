@@ -0,0 +1,296 @@
+package subscription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"payment-service-iae/gateway"
+	"payment-service-iae/payment"
+)
+
+// startDelay is how long after creation the first charge runs. The gateway
+// rejects schedules starting in the past, so "now" is not safe.
+const startDelay = time.Minute
+
+// CreateRequest is a customer signing up for a plan with a saved card
+// token or a linked GoPay account.
+type CreateRequest struct {
+	PlanID         string
+	CustomerID     string
+	PaymentType    string
+	Token          string
+	GopayAccountID string
+	Customer       *gateway.Customer
+}
+
+// Service keeps local subscriptions in step with the gateway's schedules and
+// turns renewal charges into payments.
+type Service struct {
+	store    Store
+	plans    *Plans
+	gateway  gateway.Subscriptions
+	payments *payment.Service
+}
+
+func NewService(store Store, plans *Plans, gw gateway.Subscriptions, payments *payment.Service) *Service {
+	return &Service{store: store, plans: plans, gateway: gw, payments: payments}
+}
+
+func (s *Service) Plans() []Plan {
+	return s.plans.List()
+}
+
+func (s *Service) Get(ctx context.Context, id string) (*Subscription, error) {
+	return s.store.Get(ctx, id)
+}
+
+func (s *Service) ListByCustomer(ctx context.Context, customerID string) ([]Subscription, error) {
+	return s.store.ListByCustomer(ctx, customerID)
+}
+
+// Create starts the schedule at the gateway and stores the subscription.
+// The first charge runs shortly after and arrives like any renewal.
+func (s *Service) Create(ctx context.Context, req CreateRequest) (*Subscription, error) {
+	plan, err := s.plans.Get(req.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	switch req.PaymentType {
+	case PaymentTypeCreditCard:
+		if req.GopayAccountID != "" {
+			return nil, fmt.Errorf("%w: gopayAccountId is only used with gopay", ErrInvalidRequest)
+		}
+	case PaymentTypeGopay:
+		if req.GopayAccountID == "" {
+			return nil, fmt.Errorf("%w: gopayAccountId is required for gopay", ErrInvalidRequest)
+		}
+	default:
+		return nil, fmt.Errorf("%w: payment type must be %s or %s", ErrInvalidRequest, PaymentTypeCreditCard, PaymentTypeGopay)
+	}
+	if req.Token == "" {
+		return nil, fmt.Errorf("%w: token is required", ErrInvalidRequest)
+	}
+
+	// Check before the gateway call so a duplicate does not leave an
+	// orphaned schedule behind; the store enforces it again on Create.
+	existing, err := s.store.ListByCustomer(ctx, req.CustomerID)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range existing {
+		if e.PlanID == plan.ID && e.Status.Live() {
+			return nil, ErrAlreadySubscribed
+		}
+	}
+
+	sub := &Subscription{
+		ID:           newID(),
+		PlanID:       plan.ID,
+		CustomerID:   req.CustomerID,
+		PaymentType:  req.PaymentType,
+		Amount:       plan.Price,
+		Interval:     plan.Interval,
+		IntervalUnit: plan.IntervalUnit,
+		MaxInterval:  plan.MaxInterval,
+		Status:       StatusActive,
+	}
+	startAt := time.Now().Add(startDelay).Truncate(time.Second)
+	result, err := s.gateway.CreateSubscription(ctx, gateway.SubscriptionRequest{
+		Name:           sub.ID,
+		Amount:         sub.Amount,
+		PaymentType:    sub.PaymentType,
+		Token:          req.Token,
+		GopayAccountID: req.GopayAccountID,
+		Interval:       sub.Interval,
+		IntervalUnit:   sub.IntervalUnit,
+		MaxInterval:    sub.MaxInterval,
+		StartAt:        startAt,
+		Customer:       req.Customer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sub.GatewayID = result.ID
+	sub.NextChargeAt = result.NextChargeAt
+	if sub.NextChargeAt == nil {
+		sub.NextChargeAt = &startAt
+	}
+
+	if err := s.store.Create(ctx, sub); err != nil {
+		if cancelErr := s.gateway.CancelSubscription(ctx, sub.GatewayID); cancelErr != nil {
+			log.Printf("Failed to cancel gateway subscription %s after store error: %v", sub.GatewayID, cancelErr)
+		}
+		return nil, err
+	}
+	log.Printf("Subscription %s created for plan %s", sub.ID, sub.PlanID)
+	return sub, nil
+}
+
+// Pause stops charging an active subscription until it is resumed.
+func (s *Service) Pause(ctx context.Context, id string) (*Subscription, error) {
+	sub, err := s.checkStatus(ctx, id, StatusActive, StatusPaused)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.gateway.PauseSubscription(ctx, sub.GatewayID); err != nil {
+		return nil, err
+	}
+	return s.store.Update(ctx, id, func(sub *Subscription) error {
+		if err := checkTransition(sub, StatusActive, StatusPaused); err != nil {
+			return err
+		}
+		sub.Status = StatusPaused
+		sub.NextChargeAt = nil
+		return nil
+	})
+}
+
+// Resume restarts charging a paused subscription on the gateway's schedule.
+func (s *Service) Resume(ctx context.Context, id string) (*Subscription, error) {
+	sub, err := s.checkStatus(ctx, id, StatusPaused, StatusActive)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.gateway.ResumeSubscription(ctx, sub.GatewayID)
+	if err != nil {
+		return nil, err
+	}
+	return s.store.Update(ctx, id, func(sub *Subscription) error {
+		if err := checkTransition(sub, StatusPaused, StatusActive); err != nil {
+			return err
+		}
+		sub.Status = StatusActive
+		sub.NextChargeAt = result.NextChargeAt
+		return nil
+	})
+}
+
+// Cancel stops a live subscription for good.
+func (s *Service) Cancel(ctx context.Context, id string) (*Subscription, error) {
+	sub, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.Status.Live() {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, sub.Status, StatusCancelled)
+	}
+	if err := s.gateway.CancelSubscription(ctx, sub.GatewayID); err != nil {
+		return nil, err
+	}
+	return s.store.Update(ctx, id, func(sub *Subscription) error {
+		if !sub.Status.Live() {
+			return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, sub.Status, StatusCancelled)
+		}
+		now := time.Now()
+		sub.Status = StatusCancelled
+		sub.NextChargeAt = nil
+		sub.CancelledAt = &now
+		return nil
+	})
+}
+
+// checkStatus loads the subscription and checks it can move from one
+// status to the other before the gateway is asked to.
+func (s *Service) checkStatus(ctx context.Context, id string, from, to Status) (*Subscription, error) {
+	sub, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkTransition(sub, from, to); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func checkTransition(sub *Subscription, from, to Status) error {
+	if sub.Status != from {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, sub.Status, to)
+	}
+	return nil
+}
+
+// ApplyRenewal handles the first notification for a renewal charge, which
+// has no payment yet: it records the payment for the subscription and
+// applies the update to it. Orders that are not renewals of a known
+// subscription return payment.ErrNotFound.
+func (s *Service) ApplyRenewal(ctx context.Context, orderID string, u payment.StatusUpdate) (*payment.Payment, bool, error) {
+	id := IDFromOrderID(orderID)
+	if id == "" {
+		return nil, false, payment.ErrNotFound
+	}
+	sub, err := s.store.Get(ctx, id)
+	if errors.Is(err, ErrNotFound) {
+		return nil, false, payment.ErrNotFound
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	if !u.GrossAmount.IsZero() && u.GrossAmount != sub.Amount {
+		return nil, false, fmt.Errorf("%w: got %s, subscription charges %s", payment.ErrAmountMismatch, u.GrossAmount, sub.Amount)
+	}
+
+	err = s.payments.Create(ctx, &payment.Payment{
+		OrderID:        orderID,
+		CustomerID:     sub.CustomerID,
+		Amount:         sub.Amount,
+		Status:         payment.StatusPending,
+		PaymentType:    sub.PaymentType,
+		SubscriptionID: sub.ID,
+	})
+	if err != nil && !errors.Is(err, payment.ErrAlreadyExists) {
+		return nil, false, fmt.Errorf("create renewal payment %s: %w", orderID, err)
+	}
+	log.Printf("Renewal %s recorded for subscription %s", orderID, sub.ID)
+	return s.payments.ApplyStatusUpdate(ctx, orderID, u)
+}
+
+// RecordCharge advances the billing cycle once a renewal payment is paid.
+// It is safe to call again for the same payment, and replays of older
+// renewals do not move the cycle back.
+func (s *Service) RecordCharge(ctx context.Context, p *payment.Payment) error {
+	if p.SubscriptionID == "" || !p.Status.IsPaid() {
+		return nil
+	}
+	chargedAt := p.CreatedAt
+	_, err := s.store.Update(ctx, p.SubscriptionID, func(sub *Subscription) error {
+		if sub.LastOrderID == p.OrderID || (sub.LastChargedAt != nil && chargedAt.Before(*sub.LastChargedAt)) {
+			return errUnchanged
+		}
+		sub.ChargeCount++
+		sub.LastChargedAt = &chargedAt
+		sub.LastOrderID = p.OrderID
+		switch {
+		case sub.MaxInterval > 0 && sub.ChargeCount >= sub.MaxInterval:
+			if sub.Status.Live() {
+				sub.Status = StatusEnded
+			}
+			sub.NextChargeAt = nil
+		case sub.Status == StatusActive:
+			// Count from the scheduled time rather than when the
+			// notification arrived, so late notifications do not drift the
+			// cycle.
+			next := chargedAt
+			if sub.NextChargeAt != nil && !sub.NextChargeAt.After(chargedAt) {
+				next = *sub.NextChargeAt
+			}
+			for !next.After(chargedAt) {
+				next = sub.Next(next)
+			}
+			sub.NextChargeAt = &next
+		}
+		return nil
+	})
+	if errors.Is(err, errUnchanged) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("record charge %s for subscription %s: %w", p.OrderID, p.SubscriptionID, err)
+	}
+	return nil
+}
+
+// errUnchanged aborts an Update that has nothing to write.
+var errUnchanged = errors.New("subscription unchanged")
@@ -0,0 +1,129 @@
+// Package subscription sells recurring reading plans. The gateway charges
+// each billing cycle on its own; every charge arrives as an ordinary payment
+// notification and is recorded as a payment linked to the subscription.
+package subscription
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"payment-service-iae/money"
+)
+
+var (
+	ErrNotFound          = errors.New("subscription not found")
+	ErrPlanNotFound      = errors.New("subscription plan not found")
+	ErrInvalidTransition = errors.New("invalid subscription status transition")
+	ErrAlreadySubscribed = errors.New("customer already subscribed to plan")
+	ErrInvalidRequest    = errors.New("invalid subscription request")
+)
+
+type Status string
+
+const (
+	StatusActive    Status = "active"
+	StatusPaused    Status = "paused"
+	StatusCancelled Status = "cancelled"
+	// StatusEnded is reached when a plan with a charge limit has used it up.
+	StatusEnded Status = "ended"
+)
+
+// Live reports whether the gateway may still charge the subscription.
+func (s Status) Live() bool {
+	return s == StatusActive || s == StatusPaused
+}
+
+// Payment types the gateway can charge on a schedule.
+const (
+	PaymentTypeCreditCard = "credit_card"
+	PaymentTypeGopay      = "gopay"
+)
+
+// Interval units accepted by the gateway schedule.
+const (
+	UnitDay   = "day"
+	UnitWeek  = "week"
+	UnitMonth = "month"
+)
+
+// idPrefix starts every subscription ID. The ID is also the gateway
+// subscription name, so renewal order IDs start with it.
+const idPrefix = "SUB-"
+
+const idLength = len(idPrefix) + 12
+
+type Subscription struct {
+	ID         string
+	PlanID     string
+	CustomerID string
+	// GatewayID is the subscription's ID at the gateway.
+	GatewayID   string
+	PaymentType string
+	Amount      money.Money
+	Interval    int
+	// IntervalUnit is UnitDay, UnitWeek or UnitMonth.
+	IntervalUnit string
+	// MaxInterval is how many charges the plan runs for; zero means until
+	// cancelled.
+	MaxInterval int
+	Status      Status
+	// NextChargeAt is empty while the subscription is not active.
+	NextChargeAt  *time.Time
+	LastChargedAt *time.Time
+	// LastOrderID is the last renewal counted, so a repeated notification
+	// does not advance the cycle twice.
+	LastOrderID string
+	ChargeCount int
+	CancelledAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Next returns the charge after one at t.
+func (s *Subscription) Next(t time.Time) time.Time {
+	return addInterval(t, s.Interval, s.IntervalUnit)
+}
+
+func addInterval(t time.Time, n int, unit string) time.Time {
+	switch unit {
+	case UnitDay:
+		return t.AddDate(0, 0, n)
+	case UnitWeek:
+		return t.AddDate(0, 0, 7*n)
+	default:
+		return t.AddDate(0, n, 0)
+	}
+}
+
+// newID returns a fresh subscription ID such as SUB-3fa85f6457b2.
+func newID() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return idPrefix + hex.EncodeToString(b)
+}
+
+// IDFromOrderID returns the subscription a renewal order ID belongs to, or
+// "" when the order is not a renewal.
+func IDFromOrderID(orderID string) string {
+	if !strings.HasPrefix(orderID, idPrefix) || len(orderID) <= idLength || orderID[idLength] != '-' {
+		return ""
+	}
+	return orderID[:idLength]
+}
+
+// Store keeps subscriptions by ID. At most one live subscription per
+// customer and plan is allowed; Create returns ErrAlreadySubscribed for a
+// second one.
+type Store interface {
+	Create(ctx context.Context, s *Subscription) error
+	Get(ctx context.Context, id string) (*Subscription, error)
+	// ListByCustomer returns the customer's subscriptions, newest first.
+	ListByCustomer(ctx context.Context, customerID string) ([]Subscription, error)
+	// Update loads the subscription, lets fn modify it and saves the result
+	// atomically. If fn returns an error nothing is written.
+	Update(ctx context.Context, id string, fn func(s *Subscription) error) (*Subscription, error)
+}
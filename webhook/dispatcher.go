@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// A delivery's lease must outlast the request timeout so that no two
+	// dispatchers send it at once.
+	leaseMargin = 30 * time.Second
+	maxBackoff  = time.Hour
+	firstRetry  = 30 * time.Second
+)
+
+// Dispatcher sends due deliveries. A delivery succeeds on any 2xx answer;
+// otherwise it is retried with exponential backoff (30s, 1m, 2m ... capped
+// at an hour) until maxAttempts, when it is marked failed.
+type Dispatcher struct {
+	store       Store
+	client      *http.Client
+	interval    time.Duration
+	batchSize   int
+	maxAttempts int
+}
+
+func NewDispatcher(store Store, timeout, interval time.Duration, batchSize, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		store:       store,
+		client:      &http.Client{Timeout: timeout},
+		interval:    interval,
+		batchSize:   batchSize,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.RunOnce(ctx); err != nil {
+			log.Printf("Webhook dispatcher: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce sends one batch of due deliveries and returns how many were
+// attempted.
+func (d *Dispatcher) RunOnce(ctx context.Context) (int, error) {
+	jobs, err := d.store.Claim(ctx, d.batchSize, d.client.Timeout+leaseMargin)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, job := range jobs {
+		attempt := d.send(ctx, job)
+		delivery := job.Delivery
+		d.apply(&delivery, attempt)
+		if err := d.store.Record(ctx, &delivery, attempt); err != nil {
+			return 0, err
+		}
+	}
+	return len(jobs), nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, job Job) Attempt {
+	start := time.Now()
+	attempt := Attempt{AttemptedAt: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(job.Delivery.Payload))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	timestamp := start.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "payment-service-webhooks")
+	req.Header.Set(HeaderDelivery, job.Delivery.ID)
+	req.Header.Set(HeaderEvent, job.Delivery.EventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(job.Secret, timestamp, job.Delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	attempt.Duration = time.Since(start)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		attempt.Error = fmt.Sprintf("endpoint answered %d", resp.StatusCode)
+	}
+	return attempt
+}
+
+// apply moves the delivery to its state after attempt.
+func (d *Dispatcher) apply(delivery *Delivery, attempt Attempt) {
+	delivery.Attempts++
+	delivery.LastStatusCode = attempt.StatusCode
+	delivery.LastError = attempt.Error
+
+	switch {
+	case attempt.Error == "":
+		delivery.Status = DeliverySucceeded
+		delivery.DeliveredAt = &attempt.AttemptedAt
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= d.maxAttempts:
+		delivery.Status = DeliveryFailed
+		delivery.NextAttemptAt = nil
+		log.Printf("Webhook delivery %s of %s gave up after %d attempts: %s", delivery.ID, delivery.EventType, delivery.Attempts, attempt.Error)
+	default:
+		delivery.Status = DeliveryPending
+		next := attempt.AttemptedAt.Add(backoff(delivery.Attempts))
+		delivery.NextAttemptAt = &next
+	}
+}
+
+// backoff is the wait after the given number of failed attempts.
+func backoff(attempts int) time.Duration {
+	if attempts > 8 {
+		return maxBackoff
+	}
+	return min(firstRetry<<(attempts-1), maxBackoff)
+}
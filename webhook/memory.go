@@ -0,0 +1,211 @@
+package webhook
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"payment-service-iae/outbox"
+)
+
+// MemoryStore is an in-process Store for tests and local development.
+type MemoryStore struct {
+	mu         sync.Mutex
+	endpoints  []Endpoint
+	deliveries []Delivery
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) CreateEndpoint(ctx context.Context, e *Endpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	e.ID = uuid.NewString()
+	e.CreatedAt = now
+	e.UpdatedAt = now
+	s.endpoints = append(s.endpoints, cloneEndpoint(e))
+	return nil
+}
+
+func (s *MemoryStore) UpdateEndpoint(ctx context.Context, id string, fn func(e *Endpoint) error) (*Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.endpoints, func(e Endpoint) bool { return e.ID == id })
+	if i < 0 {
+		return nil, ErrEndpointNotFound
+	}
+	e := cloneEndpoint(&s.endpoints[i])
+	if err := fn(&e); err != nil {
+		return nil, err
+	}
+	e.UpdatedAt = time.Now()
+	s.endpoints[i] = cloneEndpoint(&e)
+	return &e, nil
+}
+
+func (s *MemoryStore) DeleteEndpoint(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.endpoints, func(e Endpoint) bool { return e.ID == id })
+	if i < 0 {
+		return ErrEndpointNotFound
+	}
+	s.endpoints = slices.Delete(s.endpoints, i, i+1)
+	s.deliveries = slices.DeleteFunc(s.deliveries, func(d Delivery) bool { return d.EndpointID == id })
+	return nil
+}
+
+func (s *MemoryStore) ListEndpoints(ctx context.Context) ([]Endpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	endpoints := make([]Endpoint, 0, len(s.endpoints))
+	for i := range s.endpoints {
+		endpoints = append(endpoints, cloneEndpoint(&s.endpoints[i]))
+	}
+	return endpoints, nil
+}
+
+func (s *MemoryStore) Enqueue(ctx context.Context, e outbox.Event, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, endpoint := range s.endpoints {
+		if !endpoint.Subscribed(e.Type) {
+			continue
+		}
+		exists := slices.ContainsFunc(s.deliveries, func(d Delivery) bool {
+			return d.EndpointID == endpoint.ID && d.EventID == e.ID
+		})
+		if exists {
+			continue
+		}
+		s.deliveries = append(s.deliveries, Delivery{
+			ID:            uuid.NewString(),
+			EndpointID:    endpoint.ID,
+			EventID:       e.ID,
+			EventType:     e.Type,
+			OrderID:       e.AggregateID,
+			Payload:       payload,
+			Status:        DeliveryPending,
+			NextAttemptAt: &now,
+			CreatedAt:     now,
+		})
+	}
+	return nil
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	leaseEnd := now.Add(lease)
+	var jobs []Job
+	for i := range s.deliveries {
+		d := &s.deliveries[i]
+		if len(jobs) == limit {
+			break
+		}
+		if d.Status != DeliveryPending || d.NextAttemptAt == nil || d.NextAttemptAt.After(now) {
+			continue
+		}
+		e := slices.IndexFunc(s.endpoints, func(e Endpoint) bool { return e.ID == d.EndpointID })
+		if e < 0 {
+			continue
+		}
+		d.NextAttemptAt = &leaseEnd
+		jobs = append(jobs, Job{Delivery: cloneDelivery(d), URL: s.endpoints[e].URL, Secret: s.endpoints[e].Secret})
+	}
+	return jobs, nil
+}
+
+func (s *MemoryStore) Record(ctx context.Context, d *Delivery, a Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := s.find(d.ID)
+	if stored == nil {
+		return ErrDeliveryNotFound
+	}
+	log := append(stored.Log, a)
+	*stored = cloneDelivery(d)
+	stored.Log = log
+	return nil
+}
+
+func (s *MemoryStore) GetDelivery(ctx context.Context, id string) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.find(id)
+	if d == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	c := cloneDelivery(d)
+	return &c, nil
+}
+
+func (s *MemoryStore) ListDeliveries(ctx context.Context, f DeliveryFilter) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deliveries []Delivery
+	for i := len(s.deliveries) - 1; i >= 0 && (f.Limit == 0 || len(deliveries) < f.Limit); i-- {
+		d := &s.deliveries[i]
+		if (f.EndpointID != "" && d.EndpointID != f.EndpointID) ||
+			(f.OrderID != "" && d.OrderID != f.OrderID) ||
+			(f.Status != "" && d.Status != f.Status) {
+			continue
+		}
+		deliveries = append(deliveries, cloneDelivery(d))
+	}
+	return deliveries, nil
+}
+
+func (s *MemoryStore) Replay(ctx context.Context, id string) (*Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := s.find(id)
+	if d == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	now := time.Now()
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = &now
+	c := cloneDelivery(d)
+	return &c, nil
+}
+
+func (s *MemoryStore) find(id string) *Delivery {
+	for i := range s.deliveries {
+		if s.deliveries[i].ID == id {
+			return &s.deliveries[i]
+		}
+	}
+	return nil
+}
+
+func cloneEndpoint(e *Endpoint) Endpoint {
+	c := *e
+	c.EventTypes = slices.Clone(e.EventTypes)
+	return c
+}
+
+func cloneDelivery(d *Delivery) Delivery {
+	c := *d
+	c.Log = slices.Clone(d.Log)
+	return c
+}
@@ -0,0 +1,331 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"payment-service-iae/outbox"
+)
+
+const endpointColumns = `id, url, secret, event_types, active, created_at, updated_at`
+
+const deliveryColumns = `id, endpoint_id, event_id, event_type, order_id, payload, status, attempts,
+	next_attempt_at, last_status_code, last_error, delivered_at, created_at`
+
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) CreateEndpoint(ctx context.Context, e *Endpoint) error {
+	e.ID = uuid.NewString()
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_endpoints (id, url, secret, event_types, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at, updated_at`,
+		e.ID, e.URL, e.Secret, strings.Join(e.EventTypes, ","), e.Active,
+	).Scan(&e.CreatedAt, &e.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) UpdateEndpoint(ctx context.Context, id string, fn func(e *Endpoint) error) (*Endpoint, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin update webhook endpoint %s: %w", id, err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRowContext(ctx, `SELECT `+endpointColumns+` FROM webhook_endpoints WHERE id = $1 FOR UPDATE`, id)
+	e, err := scanEndpoint(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrEndpointNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select webhook endpoint %s: %w", id, err)
+	}
+
+	if err := fn(e); err != nil {
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE webhook_endpoints
+		SET url = $2, secret = $3, event_types = $4, active = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at`,
+		id, e.URL, e.Secret, strings.Join(e.EventTypes, ","), e.Active,
+	).Scan(&e.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("update webhook endpoint %s: %w", id, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit webhook endpoint %s: %w", id, err)
+	}
+	return e, nil
+}
+
+func (s *PostgresStore) DeleteEndpoint(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete webhook endpoint %s: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrEndpointNotFound
+	}
+	return nil
+}
+
+func (s *PostgresStore) ListEndpoints(ctx context.Context) ([]Endpoint, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+endpointColumns+` FROM webhook_endpoints ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("select webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []Endpoint
+	for rows.Next() {
+		e, err := scanEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, *e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select webhook endpoints: %w", err)
+	}
+	return endpoints, nil
+}
+
+func (s *PostgresStore) Enqueue(ctx context.Context, e outbox.Event, payload []byte) error {
+	endpoints, err := s.ListEndpoints(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, endpoint := range endpoints {
+		if !endpoint.Subscribed(e.Type) {
+			continue
+		}
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, order_id, payload, status, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+			ON CONFLICT (endpoint_id, event_id) DO NOTHING`,
+			uuid.NewString(), endpoint.ID, e.ID, e.Type, e.AggregateID, string(payload), DeliveryPending,
+		)
+		if err != nil {
+			return fmt.Errorf("enqueue webhook delivery of %s to %s: %w", e.ID, endpoint.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *PostgresStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = NOW() + $2::float8 * INTERVAL '1 millisecond'
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING `+deliveryColumns+`
+		)
+		SELECT c.id, c.endpoint_id, c.event_id, c.event_type, c.order_id, c.payload, c.status, c.attempts,
+			c.next_attempt_at, c.last_status_code, c.last_error, c.delivered_at, c.created_at,
+			e.url, e.secret
+		FROM claimed c
+		JOIN webhook_endpoints e ON e.id = c.endpoint_id`,
+		limit, lease.Milliseconds(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		if err := scanDelivery(rows, &job.Delivery, &job.URL, &job.Secret); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	return jobs, nil
+}
+
+func (s *PostgresStore) Record(ctx context.Context, d *Delivery, a Attempt) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin record webhook delivery %s: %w", d.ID, err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO webhook_delivery_attempts (delivery_id, attempted_at, status_code, error, duration_ms)
+		VALUES ($1, $2, $3, $4, $5)`,
+		d.ID, a.AttemptedAt, a.StatusCode, a.Error, a.Duration.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert webhook attempt for %s: %w", d.ID, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = $2, attempts = $3, next_attempt_at = $4, last_status_code = $5, last_error = $6, delivered_at = $7
+		WHERE id = $1`,
+		d.ID, d.Status, d.Attempts, d.NextAttemptAt, d.LastStatusCode, d.LastError, d.DeliveredAt,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook delivery %s: %w", d.ID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit webhook delivery %s: %w", d.ID, err)
+	}
+	return nil
+}
+
+func (s *PostgresStore) GetDelivery(ctx context.Context, id string) (*Delivery, error) {
+	var d Delivery
+	row := s.db.QueryRowContext(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id)
+	err := scanDelivery(row, &d)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select webhook delivery %s: %w", id, err)
+	}
+	if d.Log, err = s.loadAttempts(ctx, id); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func (s *PostgresStore) ListDeliveries(ctx context.Context, f DeliveryFilter) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+deliveryColumns+`
+		FROM webhook_deliveries
+		WHERE ($1 = '' OR endpoint_id = $1)
+			AND ($2 = '' OR order_id = $2)
+			AND ($3 = '' OR status = $3)
+		ORDER BY created_at DESC
+		LIMIT $4`,
+		f.EndpointID, f.OrderID, string(f.Status), f.Limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var d Delivery
+		if err := scanDelivery(rows, &d); err != nil {
+			return nil, fmt.Errorf("scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select webhook deliveries: %w", err)
+	}
+	rows.Close()
+
+	for i := range deliveries {
+		if deliveries[i].Log, err = s.loadAttempts(ctx, deliveries[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return deliveries, nil
+}
+
+func (s *PostgresStore) Replay(ctx context.Context, id string) (*Delivery, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_deliveries
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1`,
+		id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("replay webhook delivery %s: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, ErrDeliveryNotFound
+	}
+	return s.GetDelivery(ctx, id)
+}
+
+func (s *PostgresStore) loadAttempts(ctx context.Context, deliveryID string) ([]Attempt, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT attempted_at, status_code, error, duration_ms
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY id`,
+		deliveryID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("select webhook attempts for %s: %w", deliveryID, err)
+	}
+	defer rows.Close()
+
+	var attempts []Attempt
+	for rows.Next() {
+		var a Attempt
+		var ms int64
+		if err := rows.Scan(&a.AttemptedAt, &a.StatusCode, &a.Error, &ms); err != nil {
+			return nil, fmt.Errorf("scan webhook attempt for %s: %w", deliveryID, err)
+		}
+		a.Duration = time.Duration(ms) * time.Millisecond
+		attempts = append(attempts, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("select webhook attempts for %s: %w", deliveryID, err)
+	}
+	return attempts, nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEndpoint(row rowScanner) (*Endpoint, error) {
+	e := &Endpoint{}
+	var eventTypes string
+	if err := row.Scan(&e.ID, &e.URL, &e.Secret, &eventTypes, &e.Active, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if eventTypes != "" {
+		e.EventTypes = strings.Split(eventTypes, ",")
+	}
+	return e, nil
+}
+
+func scanDelivery(row rowScanner, d *Delivery, extra ...any) error {
+	var payload string
+	dest := []any{
+		&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &d.OrderID, &payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	d.Payload = []byte(payload)
+	return nil
+}
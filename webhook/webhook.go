@@ -0,0 +1,160 @@
+// Package webhook delivers payment events to partner HTTPS endpoints. Events
+// come from the outbox, are stored as deliveries per subscribed endpoint and
+// sent by a Dispatcher with HMAC signatures and exponential backoff.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strconv"
+	"time"
+
+	"payment-service-iae/outbox"
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// Header names sent with every delivery.
+const (
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+type Endpoint struct {
+	ID     string
+	URL    string
+	Secret string
+	// EventTypes filters which events are sent; empty means all.
+	EventTypes []string
+	Active     bool
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Subscribed reports whether the endpoint wants events of eventType.
+func (e *Endpoint) Subscribed(eventType string) bool {
+	return e.Active && (len(e.EventTypes) == 0 || slices.Contains(e.EventTypes, eventType))
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one event sent to one endpoint, with its attempt history.
+type Delivery struct {
+	ID             string
+	EndpointID     string
+	EventID        string
+	EventType      string
+	OrderID        string
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  *time.Time
+	LastStatusCode int
+	LastError      string
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+	Log            []Attempt
+}
+
+// Attempt is one HTTP request made for a delivery. StatusCode is 0 when no
+// response was received.
+type Attempt struct {
+	AttemptedAt time.Time
+	StatusCode  int
+	Error       string
+	Duration    time.Duration
+}
+
+// Job is a claimed delivery together with where and how to send it.
+type Job struct {
+	Delivery Delivery
+	URL      string
+	Secret   string
+}
+
+// DeliveryFilter narrows ListDeliveries; zero values match everything.
+type DeliveryFilter struct {
+	EndpointID string
+	OrderID    string
+	Status     DeliveryStatus
+	Limit      int
+}
+
+type Store interface {
+	CreateEndpoint(ctx context.Context, e *Endpoint) error
+	// UpdateEndpoint loads the endpoint, lets fn modify it and saves it.
+	UpdateEndpoint(ctx context.Context, id string, fn func(e *Endpoint) error) (*Endpoint, error)
+	DeleteEndpoint(ctx context.Context, id string) error
+	ListEndpoints(ctx context.Context) ([]Endpoint, error)
+
+	// Enqueue creates a pending delivery for every active endpoint
+	// subscribed to the event's type. Enqueueing the same event again
+	// creates nothing new.
+	Enqueue(ctx context.Context, e outbox.Event, payload []byte) error
+	// Claim leases up to limit due deliveries for lease, so a crashed
+	// dispatcher's work is picked up again once the lease runs out.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	// Record logs an attempt and stores the delivery's resulting state.
+	Record(ctx context.Context, d *Delivery, a Attempt) error
+	GetDelivery(ctx context.Context, id string) (*Delivery, error)
+	ListDeliveries(ctx context.Context, f DeliveryFilter) ([]Delivery, error)
+	// Replay makes a delivery pending again with a fresh attempt budget,
+	// keeping its log.
+	Replay(ctx context.Context, id string) (*Delivery, error)
+}
+
+// Sign computes the X-Webhook-Signature value for a body sent at timestamp:
+// "sha256=" + hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a received signature and rejects timestamps further than
+// tolerance from now, which stops old deliveries being replayed.
+func Verify(secret, timestamp, signature string, body []byte, tolerance time.Duration) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	if age := time.Since(time.Unix(ts, 0)); age > tolerance || age < -tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+// Enqueuer is the outbox broker that feeds webhooks: it turns each
+// published event into deliveries.
+type Enqueuer struct {
+	store Store
+}
+
+func NewEnqueuer(store Store) *Enqueuer {
+	return &Enqueuer{store: store}
+}
+
+func (q *Enqueuer) Publish(ctx context.Context, e outbox.Event) error {
+	payload, err := e.Envelope()
+	if err != nil {
+		return err
+	}
+	return q.store.Enqueue(ctx, e, payload)
+}
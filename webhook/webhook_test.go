@@ -0,0 +1,111 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"payment-service-iae/outbox"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp int64
+		body      string
+		want      string
+	}{
+		// HMAC-SHA256("secret", "1700000000.{}")
+		{"secret", 1700000000, "{}", "sha256=b8569b78799ff9e3cbff0fc2d63a33a2b57f3282abd07c37ae5e8e7d79a5f163"},
+	}
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %d, %q) = %q, want %q", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+	if Sign("secret", 1700000000, []byte("{}")) == Sign("secret", 1700000001, []byte("{}")) {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"type":"payment.settled"}`)
+	now := time.Now().Unix()
+	sig := Sign(secret, now, body)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      []byte
+		want      bool
+	}{
+		{"valid", secret, strconv.FormatInt(now, 10), sig, body, true},
+		{"wrong secret", "other", strconv.FormatInt(now, 10), sig, body, false},
+		{"tampered body", secret, strconv.FormatInt(now, 10), sig, []byte(`{"type":"payment.refunded"}`), false},
+		{"timestamp not the signed one", secret, strconv.FormatInt(now-1, 10), sig, body, false},
+		{"missing prefix", secret, strconv.FormatInt(now, 10), sig[len("sha256="):], body, false},
+		{"empty signature", secret, strconv.FormatInt(now, 10), "", body, false},
+		{"malformed timestamp", secret, "yesterday", sig, body, false},
+		{"too old", secret, strconv.FormatInt(now-600, 10), Sign(secret, now-600, body), body, false},
+		{"too far ahead", secret, strconv.FormatInt(now+600, 10), Sign(secret, now+600, body), body, false},
+		{"within tolerance", secret, strconv.FormatInt(now-60, 10), Sign(secret, now-60, body), body, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.timestamp, tt.signature, tt.body, 5*time.Minute); got != tt.want {
+				t.Errorf("Verify = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherSignsDeliveries(t *testing.T) {
+	const secret = "whsec_test"
+	var verified bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		verified = Verify(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body, time.Minute)
+		if !verified {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	store := NewMemoryStore()
+	if err := store.CreateEndpoint(ctx, &Endpoint{URL: srv.URL, Secret: secret, Active: true}); err != nil {
+		t.Fatal(err)
+	}
+	event, err := outbox.NewEvent("payment.settled", "order-1", map[string]string{"order_id": "order-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := NewEnqueuer(store).Publish(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := NewDispatcher(store, 5*time.Second, time.Second, 10, 3).RunOnce(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("sent %d deliveries, want 1", n)
+	}
+	if !verified {
+		t.Error("endpoint could not verify the signature")
+	}
+	deliveries, err := store.ListDeliveries(ctx, DeliveryFilter{OrderID: "order-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].Status != DeliverySucceeded {
+		t.Errorf("deliveries = %+v, want one succeeded", deliveries)
+	}
+}